@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/algermosen/go-erdos/internal/db"
+	"github.com/algermosen/go-erdos/util"
+	"github.com/spf13/cobra"
+)
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Writes a native backup of a database with BACKUP DATABASE",
+	Long: `This command has the server write a full backup of the --conn database with
+BACKUP DATABASE ... WITH FORMAT, COMPRESSION. Unlike dump, which produces SQL statements,
+the backup is a byte-for-byte snapshot holding everything the database has (indexes,
+permissions, statistics, ...). It is loaded back with restore.
+
+The --to path is on the database server, not on the machine running erdos, and the
+server's service account must be allowed to write there. An existing file at that path
+is overwritten.
+
+With --copy-only, the backup does not reset the differential base nor break the log
+chain, so it can be taken without disturbing a regular backup schedule.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Retrieve flag values
+		connFlag, _ := cmd.Flags().GetString("conn")
+		connFile, _ := cmd.Flags().GetString("conn-file")
+		connectTimeout, _ := cmd.Flags().GetDuration("connect-timeout")
+		to, _ := cmd.Flags().GetString("to")
+		copyOnly, _ := cmd.Flags().GetBool("copy-only")
+
+		// Validate required parameters
+		connStr, err := util.ResolveConnString(connFlag, connFile)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		if util.IsEmpty(to) {
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "the --to flag is required", nil))
+			os.Exit(1)
+		}
+
+		dbType := resolveDBType(cmd, connStr)
+		driver, err := db.GetDriver(dbType, db.Config{
+			ConnectTimeout: connectTimeout,
+			Progress:       util.NewTerminalProgress(),
+		})
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		backupper, ok := driver.(db.Backupper)
+		if !ok {
+			appLogger.Error(errUnsupported(dbType, "native backups"))
+			os.Exit(1)
+		}
+		sqlDB, err := driver.Connect(cmd.Context(), connStr)
+		if err != nil {
+			appLogger.Error(fmt.Errorf("failed to connect to database: %w", err))
+			os.Exit(1)
+		}
+		defer sqlDB.Close()
+		logProgress("[Database connected]")
+
+		if err := backupper.Backup(cmd.Context(), sqlDB, to, copyOnly); err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		logProgress("[Backup written to %s]", to)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+
+	// Define flags
+	backupCmd.Flags().String("to", "", "Path of the backup file, on the database server (required)")
+	backupCmd.Flags().Bool("copy-only", false, "Take a copy-only backup, leaving the regular backup sequence untouched")
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// dumpConfigKeys are the settings a dump config file may hold, named after the matching flags.
+var dumpConfigKeys = []string{"dbtype", "conn", "skip", "skip-data", "include", "batch-size", "output"}
+
+// applyConfigFile reads a YAML config file and uses its values for the flags in keys
+// that were not given on the command line, so flags always override the file.
+// Keys outside of keys are rejected rather than silently ignored.
+func applyConfigFile(cmd *cobra.Command, path string, keys []string) error {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		msg := fmt.Sprintf("failed to read config file %s", path)
+		return apperrors.New(apperrors.ErrConfigNotFound, msg, err)
+	}
+
+	for _, key := range v.AllKeys() {
+		if !slices.Contains(keys, key) {
+			msg := fmt.Sprintf("unknown key '%s' in config file %s (supported: %s)", key, path, strings.Join(keys, ", "))
+			return apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+		}
+		if cmd.Flags().Changed(key) {
+			continue
+		}
+
+		// Lists may be written as YAML sequences or as comma-separated strings.
+		value := v.GetString(key)
+		if list, ok := v.Get(key).([]interface{}); ok {
+			items := make([]string, len(list))
+			for i, item := range list {
+				items[i] = fmt.Sprint(item)
+			}
+			value = strings.Join(items, ",")
+		}
+		if err := cmd.Flags().Set(key, value); err != nil {
+			msg := fmt.Sprintf("invalid value for '%s' in config file %s", key, path)
+			return apperrors.New(apperrors.ErrInvalidInput, msg, err)
+		}
+	}
+	return nil
+}
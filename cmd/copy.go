@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/algermosen/go-erdos/internal/db"
+	"github.com/algermosen/go-erdos/util"
+	"github.com/spf13/cobra"
+)
+
+// copyCmd represents the copy command
+var copyCmd = &cobra.Command{
+	Use:   "copy",
+	Short: "Copies a database's schema and data directly into other databases",
+	Long: `This command copies the schema, data and constraints of the source database into the
+target database, without writing an intermediate dump file. Tables are created and
+loaded in dependency order, and the constraints are added once all the data is in.
+
+The target is expected to be empty: existing tables are not dropped or merged. As the
+copy writes to the target, it asks for confirmation first; pass --yes to skip the prompt,
+which is required when standard input is not a terminal.
+
+Repeat --target to seed several identical databases at once, e.g. QA and staging: the
+schema and constraints are read from the source once, and every target is then copied
+into at the same time. A failing target does not stop the others; once all are done, the
+result of each is printed, and the command fails if any of them did.
+
+Rows are sent with bound parameters rather than as generated SQL text, so values never
+need escaping. Use --skip to create some tables without copying their rows; like dump
+--skip, it takes glob patterns and re: regexes. Use --bulk to set how many rows are sent
+per INSERT statement (at most 1000, and fewer for wide tables, as SQL Server accepts at
+most 2100 parameters per statement).
+
+With --bulk-copy, rows are streamed with SQL Server's bulk load protocol, which is much
+faster for large tables. Tables with an identity column, or with columns bulk load cannot
+handle (money, xml, geography, ...), are still copied with INSERT statements.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Retrieve flag values
+		source, _ := cmd.Flags().GetString("source")
+		targets, _ := cmd.Flags().GetStringArray("target")
+		dbType, _ := cmd.Flags().GetString("dbtype")
+		skip, _ := cmd.Flags().GetString("skip")
+		bulk, _ := cmd.Flags().GetInt("bulk")
+		bulkCopy, _ := cmd.Flags().GetBool("bulk-copy")
+		maxRetries, _ := cmd.Flags().GetInt("max-retries")
+		retryBackoff, _ := cmd.Flags().GetDuration("retry-backoff")
+		retryErrors, _ := cmd.Flags().GetInt32Slice("retry-errors")
+		connectTimeout, _ := cmd.Flags().GetDuration("connect-timeout")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		// Validate required parameters
+		if util.IsEmpty(source) || len(targets) == 0 || slices.ContainsFunc(targets, util.IsEmpty) {
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "both --source and --target flags are required", nil))
+			os.Exit(1)
+		}
+		unique := slices.Clone(targets)
+		slices.Sort(unique)
+		if len(slices.Compact(unique)) < len(targets) {
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "the same --target is given more than once", nil))
+			os.Exit(1)
+		}
+		if bulk < 1 || bulk > 1000 {
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "--bulk must be between 1 and 1000", nil))
+			os.Exit(1)
+		}
+
+		skipTables := util.SplitAndTrim(skip, ",")
+		skipPatterns, err := util.ParseTablePatterns(skipTables)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+
+		if !util.IsQuiet() {
+			fmt.Println("Starting database copy with the following parameters:")
+			fmt.Println(" - Source:", db.RedactConnString(source))
+			for _, target := range targets {
+				fmt.Println(" - Target:", db.RedactConnString(target))
+			}
+			fmt.Println(" - Database Type:", dbType)
+			fmt.Println(" - Skip Data From:", skipTables)
+			fmt.Println(" - Bulk Size:", bulk)
+			fmt.Println(" - Bulk Copy:", bulkCopy)
+		}
+
+		cfg := db.Config{
+			Retry: db.RetryPolicy{
+				MaxRetries:   maxRetries,
+				Backoff:      retryBackoff,
+				ErrorNumbers: retryErrors,
+			},
+			ConnectTimeout:  connectTimeout,
+			InsertBatchSize: bulk,
+			BulkCopy:        bulkCopy,
+			Progress:        util.NewTerminalProgress(),
+		}
+		driver, err := newCopyDriver(dbType, cfg)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		// Every target gets a driver of its own. Their progress would overwrite each other's
+		// line, so with several targets only the per-target steps are logged.
+		if len(targets) > 1 {
+			cfg.Progress = nil
+		}
+		copyTargets := make([]*copyTarget, len(targets))
+		descriptions := make([]string, len(targets))
+		for i, target := range targets {
+			targetDriver := driver
+			if len(targets) > 1 {
+				if targetDriver, err = newCopyDriver(dbType, cfg); err != nil {
+					appLogger.Error(err)
+					os.Exit(1)
+				}
+			}
+			copyTargets[i] = &copyTarget{connStr: target, name: describeTarget(target), driver: targetDriver}
+			descriptions[i] = copyTargets[i].name
+		}
+
+		if err := util.ConfirmModify(strings.Join(descriptions, ", "), yes); err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+
+		start := time.Now()
+		err = copyDatabase(cmd.Context(), driver, source, copyTargets, skipPatterns)
+		if err == nil && len(copyTargets) == 1 {
+			err = copyTargets[0].err
+		}
+		if err == nil && len(copyTargets) > 1 {
+			err = reportCopyResults(copyTargets)
+		}
+		if err != nil {
+			appLogger.Error(err)
+			if isInterrupted(err) {
+				os.Exit(exitInterrupted)
+			}
+			os.Exit(1)
+		}
+		log.Printf("[Database copied in %s]", time.Since(start).Round(time.Millisecond))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(copyCmd)
+
+	// Define flags
+	copyCmd.Flags().String("source", "", "Connection string of the database to copy from (required)")
+	copyCmd.Flags().StringArray("target", nil, "Connection string of a database to copy into (required, repeatable)")
+	copyCmd.Flags().String("skip", "", "Comma-separated list of tables whose data is not copied; accepts glob patterns and re: regexes")
+	copyCmd.Flags().Int("bulk", 1000, "Number of rows sent per INSERT statement (1-1000)")
+	copyCmd.Flags().Bool("bulk-copy", false, "Load rows with SQL Server bulk copy instead of INSERT statements")
+	copyCmd.Flags().Int("max-retries", db.DefaultRetryPolicy.MaxRetries, "How many times to retry a query failing with a transient error (deadlock, timeout)")
+	copyCmd.Flags().Duration("retry-backoff", db.DefaultRetryPolicy.Backoff, "Delay before the first retry, doubled on each following attempt")
+	copyCmd.Flags().Int32Slice("retry-errors", db.DefaultRetryPolicy.ErrorNumbers, "Comma-separated SQL Server error numbers retried as transient")
+	copyCmd.Flags().BoolP("yes", "y", false, "Copy into the targets without asking for confirmation")
+}
+
+// copyDriver is a driver able to copy data between databases, as copy requires.
+type copyDriver interface {
+	db.DatabaseDriver
+	db.Copier
+}
+
+// newCopyDriver returns the driver of dbType, failing if it cannot copy data.
+func newCopyDriver(dbType string, cfg db.Config) (copyDriver, error) {
+	driver, err := db.GetDriver(dbType, cfg)
+	if err != nil {
+		return nil, err
+	}
+	copier, ok := driver.(copyDriver)
+	if !ok {
+		return nil, errUnsupported(dbType, "copying data")
+	}
+	return copier, nil
+}
+
+// copyTarget is a database a copy writes to, with the driver copying into it and the outcome.
+type copyTarget struct {
+	connStr string
+	name    string // Names the database without the credentials, see describeTarget.
+	driver  copyDriver
+	err     error
+}
+
+// copyDatabase reads the schema and constraints of the source once, then copies into every
+// target at the same time: it creates the tables, copies their rows and adds the constraints.
+// The error of each target is recorded in it, so one failing does not stop the others; only
+// a failure on the source side is returned.
+func copyDatabase(ctx context.Context, driver db.DatabaseDriver, source string, targets []*copyTarget, skipTables util.TablePatterns) error {
+	sourceDB, err := driver.Connect(ctx, source)
+	if err != nil {
+		return fmt.Errorf("failed to connect to source database: %w", err)
+	}
+	defer sourceDB.Close()
+	logProgress("[Source database connected]")
+
+	// The skip list only leaves rows out, so every table is still created on the targets.
+	schema, err := driver.DumpSchema(ctx, sourceDB, nil)
+	if err != nil {
+		return err
+	}
+	constraints, err := driver.DumpConstraints(ctx, sourceDB)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target *copyTarget) {
+			defer wg.Done()
+			target.err = copyInto(ctx, target, sourceDB, schema, constraints, skipTables)
+		}(target)
+	}
+	wg.Wait()
+	return nil
+}
+
+// copyInto copies the source into one target, given the schema and constraints of the source.
+func copyInto(ctx context.Context, target *copyTarget, sourceDB *sql.DB, schema, constraints string, skipTables util.TablePatterns) error {
+	targetDB, err := target.driver.Connect(ctx, target.connStr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to target database: %w", err)
+	}
+	defer targetDB.Close()
+
+	logProgress("[Copying schema into %s]", target.name)
+	if err := executeScript(ctx, targetDB, schema); err != nil {
+		return fmt.Errorf("failed to copy schema: %w", err)
+	}
+
+	// Rows go straight from one connection to the other as query parameters.
+	logProgress("[Copying data into %s]", target.name)
+	if err := target.driver.CopyData(ctx, sourceDB, targetDB, skipTables); err != nil {
+		return err
+	}
+
+	logProgress("[Copying constraints into %s]", target.name)
+	if err := executeScript(ctx, targetDB, constraints); err != nil {
+		return fmt.Errorf("failed to copy constraints: %w", err)
+	}
+	return nil
+}
+
+// reportCopyResults prints the outcome of every target and returns an error if any failed.
+// An interruption is reported as such, even if other targets failed for another reason.
+func reportCopyResults(targets []*copyTarget) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Target\tResult\t")
+	failed := 0
+	var interrupted error
+	for _, target := range targets {
+		result := "OK"
+		if target.err != nil {
+			result = "FAILED: " + target.err.Error()
+			failed++
+			if isInterrupted(target.err) {
+				interrupted = target.err
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t\n", target.name, result)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if interrupted != nil {
+		return apperrors.New(apperrors.ErrInterrupted, "copy interrupted", interrupted)
+	}
+	if failed > 0 {
+		msg := fmt.Sprintf("copy failed for %d of %d targets", failed, len(targets))
+		return apperrors.New(apperrors.ErrMigrateProcess, msg, nil)
+	}
+	return nil
+}
+
+// executeScript runs every batch of a script against the database, in order.
+func executeScript(ctx context.Context, sqlDB *sql.DB, script string) error {
+	statements := splitSQLStatements(script)
+	for i, stmt := range statements {
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" {
+			continue
+		}
+		if _, err := sqlDB.ExecContext(ctx, stmt); err != nil {
+			if ctx.Err() != nil {
+				return apperrors.New(apperrors.ErrInterrupted, "copy interrupted", ctx.Err())
+			}
+			msg := fmt.Sprintf("failed to execute batch %d/%d", i+1, len(statements))
+			return apperrors.New(apperrors.ErrDBQuery, msg, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/algermosen/go-erdos/internal/db"
+	"github.com/algermosen/go-erdos/util"
+	"github.com/spf13/cobra"
+)
+
+// exitMismatch is the exit code used when count --compare finds different row counts.
+const exitMismatch = 2
+
+// countCmd represents the count command
+var countCmd = &cobra.Command{
+	Use:   "count",
+	Short: "Counts the rows of every table, optionally comparing two databases",
+	Long: `This command prints the exact row count of every table, counted with SELECT COUNT_BIG(*).
+Unlike dump --estimate-only, it scans the tables, so it can take a while on large ones.
+
+With --compare, the tables of the --conn database are also counted in the database given
+to --compare, e.g. the target of a migration, and the counts are printed side by side.
+Tables whose counts differ, or that only exist on one side, are marked with MISMATCH, and
+the command then exits with code 2, so it can serve as a go/no-go check in scripts.
+
+Use --skip to leave tables out; like dump --skip, it takes glob patterns and re: regexes.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Retrieve flag values
+		connFlag, _ := cmd.Flags().GetString("conn")
+		connFile, _ := cmd.Flags().GetString("conn-file")
+		dbType, _ := cmd.Flags().GetString("dbtype")
+		connectTimeout, _ := cmd.Flags().GetDuration("connect-timeout")
+		compare, _ := cmd.Flags().GetString("compare")
+		skip, _ := cmd.Flags().GetString("skip")
+
+		// Validate required parameters
+		connStr, err := util.ResolveConnString(connFlag, connFile)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		skipPatterns, err := util.ParseTablePatterns(util.SplitAndTrim(skip, ","))
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+
+		driver, err := db.GetDriver(dbType, db.Config{
+			ConnectTimeout: connectTimeout,
+			SkipTables:     skipPatterns,
+			Progress:       util.NewTerminalProgress(),
+		})
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+
+		counts, err := countTables(cmd.Context(), driver, connStr)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		if util.IsEmpty(compare) {
+			if err := printRowCounts(counts); err != nil {
+				appLogger.Error(err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		otherCounts, err := countTables(cmd.Context(), driver, compare)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		mismatches, err := printRowCountComparison(counts, otherCounts)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		if mismatches > 0 {
+			msg := fmt.Sprintf("row counts differ for %d tables", mismatches)
+			appLogger.Error(apperrors.New(apperrors.ErrMigrateProcess, msg, nil))
+			os.Exit(exitMismatch)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(countCmd)
+
+	// Define flags
+	countCmd.Flags().String("compare", "", "Connection string of a second database whose row counts must match")
+	countCmd.Flags().String("skip", "", "Comma-separated list of tables not to count; accepts glob patterns and re: regexes")
+}
+
+// countTables connects to a database and counts the rows of each of its tables.
+func countTables(ctx context.Context, driver db.DatabaseDriver, connStr string) (map[db.TableName]int64, error) {
+	sqlDB, err := driver.Connect(ctx, connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer sqlDB.Close()
+
+	tables, err := driver.Tables(ctx, sqlDB)
+	if err != nil {
+		return nil, err
+	}
+	return driver.CountRows(ctx, sqlDB, tables)
+}
+
+// sortedTables returns the tables of the given counts, sorted and without duplicates.
+func sortedTables(counts ...map[db.TableName]int64) []db.TableName {
+	var tables []db.TableName
+	for _, c := range counts {
+		for table := range c {
+			tables = append(tables, table)
+		}
+	}
+	slices.Sort(tables)
+	return slices.Compact(tables)
+}
+
+// printRowCounts prints the row count per table and in total.
+func printRowCounts(counts map[db.TableName]int64) error {
+	tables := sortedTables(counts)
+	var total int64
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, table := range tables {
+		fmt.Fprintf(w, "%s\t%d\t\n", table, counts[table])
+		total += counts[table]
+	}
+	fmt.Fprintf(w, "Total (%d tables)\t%d\t\n", len(tables), total)
+	return w.Flush()
+}
+
+// printRowCountComparison prints the source and target row counts side by side and
+// returns how many tables do not match.
+func printRowCountComparison(source, target map[db.TableName]int64) (int, error) {
+	mismatches := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Table\tSource\tTarget\tDelta\t")
+	for _, table := range sortedTables(source, target) {
+		sourceCount, inSource := source[table]
+		targetCount, inTarget := target[table]
+		sourceText, targetText := fmt.Sprint(sourceCount), fmt.Sprint(targetCount)
+		if !inSource {
+			sourceText = "-"
+		}
+		if !inTarget {
+			targetText = "-"
+		}
+
+		status := ""
+		if !inSource || !inTarget || sourceCount != targetCount {
+			status = "MISMATCH"
+			mismatches++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%+d\t%s\n", table, sourceText, targetText, targetCount-sourceCount, status)
+	}
+	return mismatches, w.Flush()
+}
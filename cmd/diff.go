@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/algermosen/go-erdos/internal/db"
+	"github.com/algermosen/go-erdos/util"
+	"github.com/spf13/cobra"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compares the schemas of two databases",
+	Long: `This command compares the schema of the source database with the one of the target
+database, e.g. to verify a migration. It reports the tables and columns present in only
+one of them, and the columns whose type, nullability or identity differ. Both databases
+are only read.
+
+Use --include constraints to also compare the primary keys, unique constraints and foreign
+keys. Constraints are compared by table and definition, not by name, as unnamed constraints
+get a different generated name in every database.
+
+Formats:
+- "text" (default): Human-readable report.
+- "json": Object listing the differences.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Retrieve flag values
+		source, _ := cmd.Flags().GetString("source")
+		target, _ := cmd.Flags().GetString("target")
+		dbType, _ := cmd.Flags().GetString("dbtype")
+		include, _ := cmd.Flags().GetString("include")
+		format, _ := cmd.Flags().GetString("format")
+		connectTimeout, _ := cmd.Flags().GetDuration("connect-timeout")
+
+		// Validate required parameters
+		if util.IsEmpty(source) || util.IsEmpty(target) {
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "both --source and --target flags are required", nil))
+			os.Exit(1)
+		}
+		include = strings.ToLower(include)
+		if include != "tables" && include != "constraints" {
+			msg := fmt.Sprintf("unsupported --include option '%s' (options: tables, constraints)", include)
+			appLogger.Error(apperrors.New(apperrors.ErrUnsupportedOption, msg, nil))
+			os.Exit(1)
+		}
+		format = strings.ToLower(format)
+		if format != "text" && format != "json" {
+			msg := fmt.Sprintf("unsupported --format option '%s' (options: text, json)", format)
+			appLogger.Error(apperrors.New(apperrors.ErrUnsupportedOption, msg, nil))
+			os.Exit(1)
+		}
+
+		driver, err := db.GetDriver(dbType, db.Config{ConnectTimeout: connectTimeout})
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+
+		diff, err := diffDatabases(cmd.Context(), driver, dbType, source, target, include == "constraints")
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+
+		if format == "json" {
+			err = diff.WriteJSON(os.Stdout)
+		} else {
+			err = diff.WriteText(os.Stdout)
+		}
+		if err != nil {
+			appLogger.Error(apperrors.New(apperrors.ErrFileWrite, "failed to write schema diff", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	// Define flags
+	diffCmd.Flags().String("source", "", "Connection string of the reference database (required)")
+	diffCmd.Flags().String("target", "", "Connection string of the database compared to it (required)")
+	diffCmd.Flags().String("include", "tables", "What to compare (options: tables, constraints); constraints also compares keys")
+	diffCmd.Flags().String("format", "text", "Output format (options: text, json)")
+}
+
+// diffDatabases connects to both databases and compares their schemas.
+func diffDatabases(ctx context.Context, driver db.DatabaseDriver, dbType, source, target string, constraints bool) (db.SchemaDiff, error) {
+	differ, ok := driver.(db.Differ)
+	if !ok {
+		return db.SchemaDiff{}, errUnsupported(dbType, "schema diffs")
+	}
+
+	sourceDB, err := driver.Connect(ctx, source)
+	if err != nil {
+		return db.SchemaDiff{}, fmt.Errorf("failed to connect to source database: %w", err)
+	}
+	defer sourceDB.Close()
+
+	targetDB, err := driver.Connect(ctx, target)
+	if err != nil {
+		return db.SchemaDiff{}, fmt.Errorf("failed to connect to target database: %w", err)
+	}
+	defer targetDB.Close()
+
+	return differ.DiffSchema(ctx, sourceDB, targetDB, constraints)
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// dumpStatusCmd represents the dump-status command
+var dumpStatusCmd = &cobra.Command{
+	Use:   "dump-status <manifest>",
+	Short: "Shows the progress recorded in a resumable dump's manifest",
+	Long: `This command prints the tables a dump run with --resume has fully dumped so far, with
+their row counts, as recorded in its manifest (<output>.manifest.json).`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manifest, err := loadDumpManifest(args[0])
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+
+		status := "in progress (resume it with dump --resume)"
+		if manifest.Complete {
+			status = "complete"
+		}
+		fmt.Printf("Dump of %s: %s\n", manifest.Output, status)
+
+		var total int64
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, entry := range manifest.Tables {
+			fmt.Fprintf(w, "%s\t%d\t\n", entry.Table, entry.Rows)
+			total += entry.Rows
+		}
+		fmt.Fprintf(w, "Dumped (%d tables)\t%d\t\n", len(manifest.Tables), total)
+		if err := w.Flush(); err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dumpStatusCmd)
+}
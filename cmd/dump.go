@@ -1,10 +1,21 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
 
 	"github.com/algermosen/go-erdos/internal/apperrors"
 	"github.com/algermosen/go-erdos/internal/db"
@@ -16,50 +27,292 @@ import (
 var dumpCmd = &cobra.Command{
 	Use:   "dump",
 	Short: "Dumps the database schema and/or data",
-	Long: `This command allows dumping a database's schema, content, or data. 
-Supported database types: PostgreSQL, SQLite, and MSSQL.
+	Long: `This command dumps a database's schema, data and constraints as a SQL script that
+recreates it. Supported database types: PostgreSQL, SQLite, and MSSQL.
 
-Options:
-- "all" (default): Dumps both schema and data.
-- "content": Dumps only the schema (table structures, constraints).
-- "data": Dumps only the data (INSERT statements).`,
+Sections:
+- "all" (default): Dumps the schema, data and constraints.
+- "content": Dumps only the schema and constraints (tables, keys, indexes).
+- "data": Dumps only the data (INSERT statements).
+
+--no-schema, --no-data and --no-constraints each leave one section out and win over --include.
+Tables are created and their data written in dependency order, parents first, and the
+constraints and indexes come last, so the script loads into an empty database.
+
+The dump is written to a temporary file that only replaces --output once it is complete,
+with a <output>.sha256 checksum next to it (see verify-dump). Use --skip, --include-tables,
+--skip-data and the schema filters to choose the tables, --where, --limit or --sample to
+choose their rows, and --mask to anonymize columns. Long dumps can be resumed with --resume.
+
+Settings used for every dump can be kept in a YAML file given with --config; flags given on
+the command line override it. The README describes every option in detail, with examples.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		// Settings from --config fill in the flags that were not given.
+		if configFile, _ := cmd.Flags().GetString("config"); configFile != "" {
+			if err := applyConfigFile(cmd, configFile, dumpConfigKeys); err != nil {
+				appLogger.Error(err)
+				os.Exit(1)
+			}
+		}
+
 		// Retrieve flag values
-		connStr, _ := cmd.Flags().GetString("conn")
+		connFlag, _ := cmd.Flags().GetString("conn")
+		connFile, _ := cmd.Flags().GetString("conn-file")
 		dbType, _ := cmd.Flags().GetString("dbtype")
 		include, _ := cmd.Flags().GetString("include")
 		skip, _ := cmd.Flags().GetString("skip")
+		includeTables, _ := cmd.Flags().GetString("include-tables")
 		skipData, _ := cmd.Flags().GetString("skip-data")
+		includeSchema, _ := cmd.Flags().GetString("include-schema")
+		excludeSchema, _ := cmd.Flags().GetString("exclude-schema")
 		outputFile, _ := cmd.Flags().GetString("output")
+		maxRetries, _ := cmd.Flags().GetInt("max-retries")
+		retryBackoff, _ := cmd.Flags().GetDuration("retry-backoff")
+		retryErrors, _ := cmd.Flags().GetInt32Slice("retry-errors")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		maxOpenConns, _ := cmd.Flags().GetInt("max-open-conns")
+		maxIdleConns, _ := cmd.Flags().GetInt("max-idle-conns")
+		connMaxLifetime, _ := cmd.Flags().GetDuration("conn-max-lifetime")
+		connectTimeout, _ := cmd.Flags().GetDuration("connect-timeout")
+		noIdentityInsert, _ := cmd.Flags().GetBool("no-identity-insert")
+		table, _ := cmd.Flags().GetString("table")
+		withDependencies, _ := cmd.Flags().GetBool("with-dependencies")
+		estimateOnly, _ := cmd.Flags().GetBool("estimate-only")
+		summaryJSON, _ := cmd.Flags().GetString("summary-json")
+		whereFlags, _ := cmd.Flags().GetStringArray("where")
+		limit, _ := cmd.Flags().GetInt("limit")
+		sample, _ := cmd.Flags().GetInt("sample")
+		sampleMaxRows, _ := cmd.Flags().GetInt("sample-max-rows")
+		batchSize, _ := cmd.Flags().GetInt("batch-size")
+		mask, _ := cmd.Flags().GetString("mask")
+		dropExisting, _ := cmd.Flags().GetBool("drop-existing")
+		ifNotExists, _ := cmd.Flags().GetBool("if-not-exists")
+		rowCounts, _ := cmd.Flags().GetBool("row-counts")
+		xactAbort, _ := cmd.Flags().GetBool("xact-abort")
+		targetDialect, _ := cmd.Flags().GetString("target-dialect")
+		resume, _ := cmd.Flags().GetBool("resume")
+		appendOutput, _ := cmd.Flags().GetBool("append")
+		includeDescriptions, _ := cmd.Flags().GetBool("include-descriptions")
+		noSchema, _ := cmd.Flags().GetBool("no-schema")
+		noData, _ := cmd.Flags().GetBool("no-data")
+		noConstraints, _ := cmd.Flags().GetBool("no-constraints")
+		dataTransaction, _ := cmd.Flags().GetBool("data-transaction")
+		fkNoCheck, _ := cmd.Flags().GetBool("fk-nocheck")
+		fkRecheck, _ := cmd.Flags().GetBool("fk-recheck")
+		dataOrder, _ := cmd.Flags().GetString("data-order")
+		disableFKs, _ := cmd.Flags().GetBool("disable-fks")
+
+		// With --output -, stdout carries the dump, so progress and status messages go to stderr.
+		if outputFile == stdoutOutput {
+			util.SetProgressOutput(os.Stderr)
+		}
 
 		// Validate required parameters
-		if util.IsEmpty(connStr) {
-			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "--conn flag is required", nil))
+		connStr, err := util.ResolveConnString(connFlag, connFile)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		dbType = resolveDBType(cmd, connStr)
+		parts, err := parseInclude(include)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		parts, err = excludeParts(parts, noSchema, noData, noConstraints)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		if batchSize < 1 || batchSize > 1000 {
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "--batch-size must be between 1 and 1000", nil))
+			os.Exit(1)
+		}
+		if resume && outputFile == stdoutOutput {
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "--resume needs an output file, not standard output", nil))
+			os.Exit(1)
+		}
+		if appendOutput && outputFile == stdoutOutput {
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "--append needs an output file, not standard output", nil))
+			os.Exit(1)
+		}
+		if concurrency < 1 {
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "--concurrency must be at least 1", nil))
+			os.Exit(1)
+		}
+		if limit < 0 {
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "--limit must not be negative", nil))
+			os.Exit(1)
+		}
+		where, err := parseWhere(whereFlags)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		if err := checkSampleOptions(sample, sampleMaxRows, len(where) > 0, limit > 0, table != "", resume); err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		maskRules, err := db.ParseMaskRules(mask)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		dialect, err := db.ParseDialect(targetDialect)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		if dataOrder != "dependency" && dataOrder != "natural" {
+			msg := fmt.Sprintf("invalid --data-order %q (options: dependency, natural)", dataOrder)
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, msg, nil))
+			os.Exit(1)
+		}
+		if fkRecheck && !fkNoCheck {
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "--fk-recheck requires --fk-nocheck", nil))
+			os.Exit(1)
+		}
+		if err := checkDialectOptions(targetDialect, parts, dropExisting, ifNotExists, includeDescriptions, fkNoCheck); err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		skipPatterns, err := util.ParseTablePatterns(util.SplitAndTrim(skip, ","))
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		includePatterns, err := util.ParseTablePatterns(util.SplitAndTrim(includeTables, ","))
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		skipDataPatterns, err := util.ParseTablePatterns(util.SplitAndTrim(skipData, ","))
+		if err != nil {
+			appLogger.Error(err)
 			os.Exit(1)
 		}
 
 		// Process the skip tables list
 		skipTables := util.SplitAndTrim(skip, ",")
+		includeTableList := util.SplitAndTrim(includeTables, ",")
 		skipDataTables := util.SplitAndTrim(skipData, ",")
+		includeSchemas := util.SplitAndTrim(includeSchema, ",")
+		excludeSchemas := util.SplitAndTrim(excludeSchema, ",")
 
-		fmt.Println("Starting database dump with the following parameters:")
-		fmt.Println(" - Connection String:", connStr)
-		fmt.Println(" - Database Type:", dbType)
-		fmt.Println(" - Include:", include)
-		fmt.Println(" - Skip Tables:", skipTables)
-		fmt.Println(" - Skip Data From:", skipDataTables)
-		fmt.Println(" - Output File:", outputFile)
+		// Expand the placeholders of the output path, so every scheduled run gets its own file.
+		if expanded, err := expandOutputPath(cmd.Context(), outputFile, dbType, connStr, connectTimeout, time.Now()); err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		} else {
+			outputFile = expanded
+		}
+
+		if !util.IsQuiet() {
+			out := util.ProgressOutput()
+			fmt.Fprintln(out, "Starting database dump with the following parameters:")
+			fmt.Fprintln(out, " - Connection String:", db.RedactConnString(connStr))
+			fmt.Fprintln(out, " - Database Type:", dbType)
+			fmt.Fprintln(out, " - Include:", include)
+			fmt.Fprintf(out, " - Sections: schema=%t, data=%t, constraints=%t\n", parts.Schema, parts.Data, parts.Constraints)
+			fmt.Fprintln(out, " - Include Tables:", includeTableList)
+			fmt.Fprintln(out, " - Skip Tables:", skipTables)
+			fmt.Fprintln(out, " - Skip Data From:", skipDataTables)
+			fmt.Fprintln(out, " - Include Schemas:", includeSchemas)
+			fmt.Fprintln(out, " - Exclude Schemas:", excludeSchemas)
+			fmt.Fprintln(out, " - Output File:", outputFile)
+			fmt.Fprintln(out, " - Batch Size:", batchSize)
+			fmt.Fprintln(out, " - Max Retries:", maxRetries)
+			fmt.Fprintln(out, " - Retry Backoff:", retryBackoff)
+			fmt.Fprintln(out, " - Retry Errors:", retryErrors)
+			fmt.Fprintln(out, " - Concurrency:", concurrency)
+			fmt.Fprintln(out, " - Max Open Connections:", maxOpenConns)
+			fmt.Fprintln(out, " - Max Idle Connections:", maxIdleConns)
+			fmt.Fprintln(out, " - Connection Max Lifetime:", connMaxLifetime)
+			fmt.Fprintln(out, " - No Identity Insert:", noIdentityInsert)
+			fmt.Fprintln(out, " - Drop Existing:", dropExisting)
+			fmt.Fprintln(out, " - If Not Exists:", ifNotExists)
+			fmt.Fprintln(out, " - Include Descriptions:", includeDescriptions)
+			fmt.Fprintln(out, " - Row Counts:", rowCounts)
+			fmt.Fprintln(out, " - XACT_ABORT:", xactAbort)
+			fmt.Fprintln(out, " - Data Transaction:", dataTransaction)
+			fmt.Fprintln(out, " - Data Order:", dataOrder)
+			fmt.Fprintln(out, " - Disable FKs:", disableFKs)
+			fmt.Fprintln(out, " - FK NOCHECK:", fkNoCheck)
+			fmt.Fprintln(out, " - FK Re-check:", fkRecheck)
+			fmt.Fprintln(out, " - Target Dialect:", targetDialect)
+			fmt.Fprintln(out, " - Resume:", resume)
+			fmt.Fprintln(out, " - Append:", appendOutput)
+			for _, filter := range whereFlags {
+				fmt.Fprintln(out, " - Where:", filter)
+			}
+			if limit > 0 {
+				fmt.Fprintln(out, " - Row Limit:", limit)
+			}
+			if sample > 0 {
+				fmt.Fprintln(out, " - Sample:", sample)
+				fmt.Fprintln(out, " - Sample Max Rows:", sampleMaxRows)
+			}
+			if mask != "" {
+				fmt.Fprintln(out, " - Mask:", mask)
+			}
+			if table != "" {
+				fmt.Fprintln(out, " - Table:", table)
+				fmt.Fprintln(out, " - With Dependencies:", withDependencies)
+			}
+		}
 
 		options := dumpOptions{
 			connStr:        connStr,
 			dbType:         dbType,
 			include:        include,
 			outputFile:     outputFile,
-			skipTables:     skipTables,
-			skipDataTables: skipDataTables,
+			includeTables:  includePatterns,
+			skipTables:     skipPatterns,
+			skipDataTables: skipDataPatterns,
+			includeSchemas: includeSchemas,
+			excludeSchemas: excludeSchemas,
+			maxRetries:     maxRetries,
+			retryBackoff:   retryBackoff,
+			retryErrors:    retryErrors,
+			concurrency:    concurrency,
+			maxOpenConns:   maxOpenConns,
+			maxIdleConns:   maxIdleConns,
+			connLifetime:   connMaxLifetime,
+			connectTimeout: connectTimeout,
+			noIdentity:     noIdentityInsert,
+			parts:          parts,
+			table:          table,
+			withDeps:       withDependencies,
+			estimateOnly:   estimateOnly,
+			summaryJSON:    summaryJSON,
+			where:          where,
+			limit:          limit,
+			sample:         sample,
+			sampleMaxRows:  sampleMaxRows,
+			batchSize:      batchSize,
+			mask:           maskRules,
+			dropExisting:   dropExisting,
+			ifNotExists:    ifNotExists,
+			descriptions:   includeDescriptions,
+			rowCounts:      rowCounts,
+			xactAbort:      xactAbort,
+			dataTx:         dataTransaction,
+			fkNoCheck:      fkNoCheck,
+			fkRecheck:      fkRecheck,
+			naturalOrder:   dataOrder == "natural",
+			disableFKs:     disableFKs,
+			dialect:        dialect,
+			resume:         resume,
+			appendOutput:   appendOutput,
 		}
 
-		handleDump(options)
+		if err := handleDump(cmd.Context(), options); err != nil {
+			appLogger.Error(err)
+			if isInterrupted(err) {
+				os.Exit(exitInterrupted)
+			}
+			os.Exit(1)
+		}
 	},
 }
 
@@ -68,85 +321,562 @@ func init() {
 
 	// Define flags
 	dumpCmd.Flags().String("include", "all", "What to include in the dump (options: all, content, data) (default: all)")
-	dumpCmd.Flags().String("skip", "", "Comma-separated list of objects/tables to ignore")
-	dumpCmd.Flags().String("skip-data", "", "Comma-separated list of objects/tables which data need to be ignored")
-	dumpCmd.Flags().String("output", "./output/dump.sql", "File to save the database dump (default: dump.sql)")
-}
-
-func handleDump(options dumpOptions) error {
-	switch options.dbType {
-	case "postgres":
-		return dumpPostgres(options)
-	case "sqlite":
-		return dumpSQLite(options)
-	case "mssql":
-		return dumpMSSQL(options)
+	dumpCmd.Flags().Bool("no-schema", false, "Leave the CREATE statements out of the dump, whatever --include says")
+	dumpCmd.Flags().Bool("no-data", false, "Leave the INSERT statements out of the dump, whatever --include says")
+	dumpCmd.Flags().Bool("no-constraints", false, "Leave the keys and indexes out of the dump, whatever --include says")
+	dumpCmd.Flags().String("skip", "", "Comma-separated list of tables to leave out of the dump; accepts glob patterns and re: regexes")
+	dumpCmd.Flags().String("include-tables", "", "Comma-separated list of tables to dump, leaving out every other one; accepts glob patterns and re: regexes")
+	dumpCmd.Flags().String("skip-data", "", "Comma-separated list of tables whose rows are left out of the data section; accepts glob patterns and re: regexes")
+	dumpCmd.Flags().String("include-schema", "", "Comma-separated list of schemas to dump, leaving out every other one")
+	dumpCmd.Flags().String("exclude-schema", "", "Comma-separated list of schemas whose tables are left out of the dump")
+	dumpCmd.Flags().String("output", "./output/dump.sql", "File to save the database dump, or - for standard output; accepts {db}, {date} and {time}")
+	dumpCmd.Flags().Int("batch-size", 50, "Number of rows per generated INSERT statement (1-1000)")
+	dumpCmd.Flags().String("config", "", "YAML file with dump settings (dbtype, conn, skip, skip-data, include, batch-size, output); flags override it")
+	dumpCmd.Flags().Int("max-retries", db.DefaultRetryPolicy.MaxRetries, "How many times to retry a query failing with a transient error (deadlock, timeout)")
+	dumpCmd.Flags().Duration("retry-backoff", db.DefaultRetryPolicy.Backoff, "Delay before the first retry, doubled on each following attempt")
+	dumpCmd.Flags().Int32Slice("retry-errors", db.DefaultRetryPolicy.ErrorNumbers, "Comma-separated SQL Server error numbers retried as transient")
+	dumpCmd.Flags().Int("concurrency", db.DefaultConcurrency(), "Maximum number of tables dumped at the same time (1: one at a time)")
+	dumpCmd.Flags().Int("max-open-conns", 0, "Maximum open database connections (0: concurrency + 1)")
+	dumpCmd.Flags().Int("max-idle-conns", 0, "Maximum idle database connections (0: same as --max-open-conns)")
+	dumpCmd.Flags().Duration("conn-max-lifetime", 0, "Maximum time a connection may be reused (0: no limit)")
+	dumpCmd.Flags().Bool("no-identity-insert", false, "Leave identity columns out of the data so the target generates new values")
+	dumpCmd.Flags().String("table", "", "Dump only this table (e.g. dbo.Orders)")
+	dumpCmd.Flags().Bool("with-dependencies", false, "With --table, also dump every table it references")
+	dumpCmd.Flags().Bool("estimate-only", false, "Print the estimated row count of every table and exit without dumping")
+	dumpCmd.Flags().String("summary-json", "", "Also write the dump summary as JSON to this file")
+	dumpCmd.Flags().Int("limit", 0, "Maximum number of rows dumped per table (0: unlimited); each table is capped on its own, so references may dangle")
+	dumpCmd.Flags().Int("sample", 0, "Dump a foreign-key-consistent sample starting from this many random rows per unreferenced table (0: every row); not with --where, --limit, --table or --resume")
+	dumpCmd.Flags().Int("sample-max-rows", db.DefaultSampleMaxRows, "With --sample, the most rows the sample may grow to")
+	dumpCmd.Flags().Bool("drop-existing", false, "Start the schema with DROP statements for the dumped tables and the foreign keys referencing them")
+	dumpCmd.Flags().Bool("if-not-exists", false, "Only create tables that do not exist yet, so the dump can be replayed")
+	dumpCmd.Flags().Bool("include-descriptions", false, "Add the MS_Description extended properties of tables and columns to the schema")
+	dumpCmd.Flags().Bool("row-counts", false, "Keep the \"rows affected\" messages when loading the data (no SET NOCOUNT ON)")
+	dumpCmd.Flags().Bool("xact-abort", false, "Start the data with SET XACT_ABORT ON, so a failing statement aborts its batch")
+	dumpCmd.Flags().Bool("fk-nocheck", false, "Create foreign keys WITH NOCHECK, without validating the existing rows")
+	dumpCmd.Flags().Bool("fk-recheck", false, "With --fk-nocheck, validate the foreign keys after creating them all")
+	dumpCmd.Flags().String("data-order", "dependency", "Order of the tables in the data section (options: dependency, natural); natural needs the foreign keys disabled while loading")
+	dumpCmd.Flags().Bool("disable-fks", false, "Disable the constraints of every dumped table while the data loads, and re-enable them with a check afterwards")
+	dumpCmd.Flags().Bool("data-transaction", false, "Wrap the data in a single transaction, so a failed load rolls back entirely; the data section is then a single batch")
+	dumpCmd.Flags().String("target-dialect", "mssql", "Dialect the schema and constraints are written in (options: mssql, postgres)")
+	dumpCmd.Flags().Bool("append", false, "Append the dump to the output file instead of overwriting it, without repeating the session settings it holds")
+	dumpCmd.Flags().Bool("resume", false, "Record the progress of the data dump in <output>.parts/ and <output>.manifest.json, and skip the tables a previous --resume run already dumped")
+	dumpCmd.Flags().String("mask", "", "Comma-separated table.column:strategy rules masking column values (strategies: redact, hash, email, fake-name)")
+	dumpCmd.Flags().StringArray("where", nil, "Only dump the rows of a table matching a predicate on its own columns, as table:predicate (repeatable); the predicate runs verbatim")
+}
+
+// parseInclude maps the --include option onto the dump sections to write.
+func parseInclude(include string) (db.DumpParts, error) {
+	switch strings.ToLower(include) {
+	case "all":
+		return db.DumpParts{Schema: true, Data: true, Constraints: true}, nil
+	case "content":
+		return db.DumpParts{Schema: true, Constraints: true}, nil
+	case "data":
+		return db.DumpParts{Data: true}, nil
 	default:
-		msg := fmt.Sprintf("unsupported database type '%s'", options.dbType)
-		return apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+		msg := fmt.Sprintf("unsupported --include option '%s' (options: all, content, data)", include)
+		return db.DumpParts{}, apperrors.New(apperrors.ErrUnsupportedOption, msg, nil)
 	}
 }
 
-// Placeholder function for PostgreSQL dumping
-func dumpPostgres(options dumpOptions) error {
-	log.Println("Dumping PostgreSQL database...")
-	// Implement logic using pg_dump or go-pg
-	return nil
+// excludeParts leaves out the sections turned off with --no-schema, --no-data and --no-constraints.
+// They win over --include, and leaving nothing to dump is an error.
+func excludeParts(parts db.DumpParts, noSchema, noData, noConstraints bool) (db.DumpParts, error) {
+	parts.Schema = parts.Schema && !noSchema
+	parts.Data = parts.Data && !noData
+	parts.Constraints = parts.Constraints && !noConstraints
+	if !parts.Schema && !parts.Data && !parts.Constraints {
+		return parts, apperrors.New(apperrors.ErrInvalidInput, "nothing left to dump: --include and the --no-* flags exclude every section", nil)
+	}
+	return parts, nil
+}
+
+// checkDialectOptions rejects the options not supported yet when the dump is transpiled to
+// another dialect: only the schema and constraints are, while data stays T-SQL.
+func checkDialectOptions(dialect string, parts db.DumpParts, dropExisting, ifNotExists, descriptions, fkNoCheck bool) error {
+	if dialect == "" || strings.EqualFold(dialect, "mssql") {
+		return nil
+	}
+	var unsupported string
+	switch {
+	case parts.Data:
+		unsupported = "data (use --include content or --no-data)"
+	case dropExisting:
+		unsupported = "--drop-existing"
+	case ifNotExists:
+		unsupported = "--if-not-exists"
+	case descriptions:
+		unsupported = "--include-descriptions"
+	case fkNoCheck:
+		unsupported = "--fk-nocheck"
+	default:
+		return nil
+	}
+	msg := fmt.Sprintf("--target-dialect %s does not support %s yet", dialect, unsupported)
+	return apperrors.New(apperrors.ErrUnsupportedOption, msg, nil)
+}
+
+// checkSampleOptions rejects --sample values and the options a sample cannot be combined with:
+// row filters and limits would break its references, and a resumed dump would mix two samples.
+func checkSampleOptions(sample, maxRows int, where, limit, table, resume bool) error {
+	if sample < 0 {
+		return apperrors.New(apperrors.ErrInvalidInput, "--sample must not be negative", nil)
+	}
+	if sample == 0 {
+		return nil
+	}
+	if maxRows < 1 {
+		return apperrors.New(apperrors.ErrInvalidInput, "--sample-max-rows must be at least 1", nil)
+	}
+	var conflict string
+	switch {
+	case where:
+		conflict = "--where"
+	case limit:
+		conflict = "--limit"
+	case table:
+		conflict = "--table"
+	case resume:
+		conflict = "--resume"
+	default:
+		return nil
+	}
+	return apperrors.New(apperrors.ErrInvalidInput, "--sample cannot be combined with "+conflict, nil)
+}
+
+// parseWhere reads the --where values ("table:predicate") into per-table row filters, checked
+// by db.ParseWhere like those of the erdos package.
+func parseWhere(filters []string) (map[db.TableName]string, error) {
+	predicates := make(map[string]string, len(filters))
+	for _, filter := range filters {
+		name, predicate, found := strings.Cut(filter, ":")
+		if !found || strings.TrimSpace(name) == "" {
+			msg := fmt.Sprintf("invalid --where value '%s' (expected table:predicate)", filter)
+			return nil, apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+		}
+		if _, exists := predicates[name]; exists {
+			msg := fmt.Sprintf("--where given more than once for table %s", db.ParseTableName(name))
+			return nil, apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+		}
+		predicates[name] = predicate
+	}
+	return db.ParseWhere(predicates)
 }
 
-// Placeholder function for SQLite dumping
-func dumpSQLite(options dumpOptions) error {
-	log.Println("Dumping SQLite database...")
-	// Implement logic using native SQLite backup
+func handleDump(ctx context.Context, options dumpOptions) error {
+	var manifest *dumpManifest
+	var resumed map[db.TableName]db.TableDump
+	var onTableDumped func(db.TableName, db.TableDump)
+	if options.resume {
+		var err error
+		manifest, resumed, err = openDumpManifest(options.outputFile)
+		if err != nil {
+			return err
+		}
+		if len(resumed) > 0 {
+			logProgress("[Resuming dump, %d tables already dumped]", len(resumed))
+		}
+		onTableDumped = func(table db.TableName, dump db.TableDump) {
+			// Failing to record a table only means it is dumped again on resume.
+			if err := manifest.record(table, dump); err != nil {
+				appLogger.Error(err)
+			}
+		}
+	}
+
+	var omitPreamble []string
+	if options.appendOutput {
+		var err error
+		if omitPreamble, err = sessionSettings(options.outputFile); err != nil {
+			return err
+		}
+	}
+
+	driver, err := db.GetDriver(options.dbType, db.Config{
+		Retry: db.RetryPolicy{
+			MaxRetries:   options.maxRetries,
+			Backoff:      options.retryBackoff,
+			ErrorNumbers: options.retryErrors,
+		},
+		Concurrency:        options.concurrency,
+		ConnectTimeout:     options.connectTimeout,
+		NoIdentityInsert:   options.noIdentity,
+		MaxOpenConns:       options.maxOpenConns,
+		MaxIdleConns:       options.maxIdleConns,
+		ConnMaxLifetime:    options.connLifetime,
+		Where:              options.where,
+		Limit:              options.limit,
+		Sample:             options.sample,
+		SampleMaxRows:      options.sampleMaxRows,
+		InsertBatchSize:    options.batchSize,
+		IncludeSchemas:     options.includeSchemas,
+		ExcludeSchemas:     options.excludeSchemas,
+		IncludeTables:      options.includeTables,
+		SkipTables:         options.skipTables,
+		Mask:               options.mask,
+		DropExisting:       options.dropExisting,
+		IfNotExists:        options.ifNotExists,
+		Descriptions:       options.descriptions,
+		RowCounts:          options.rowCounts,
+		XactAbort:          options.xactAbort,
+		DataTransaction:    options.dataTx,
+		FKNoCheck:          options.fkNoCheck,
+		FKRecheck:          options.fkRecheck,
+		NaturalDataOrder:   options.naturalOrder,
+		DisableForeignKeys: options.disableFKs,
+		OmitPreamble:       omitPreamble,
+		TargetDialect:      options.dialect,
+		Resume:             resumed,
+		OnTableDumped:      onTableDumped,
+		Progress:           util.NewTerminalProgress(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := dumpDatabase(ctx, driver, options); err != nil {
+		return err
+	}
+	if manifest != nil {
+		return manifest.finish()
+	}
 	return nil
 }
 
-// Placeholder function for MSSQL dumping
-func dumpMSSQL(options dumpOptions) error {
-	log.Println("[Dumping MSSQL database]")
-	driver := db.MSSQLDriver{}
-	db, err := driver.Connect(options.connStr)
+// dumpDatabase writes the schema, data and constraints of the database to the output file.
+func dumpDatabase(ctx context.Context, driver db.DatabaseDriver, options dumpOptions) error {
+	logProgress("[Dumping %s database]", options.dbType)
+	sqlDB, err := driver.Connect(ctx, options.connStr)
 	if err != nil {
-		log.Fatalf("Failed to connect to source database: %v", err)
+		return fmt.Errorf("failed to connect to source database: %w", err)
 	}
-	defer db.Close()
-	log.Println("[Database connected]")
-	var dump strings.Builder
+	defer sqlDB.Close()
+	logProgress("[Database connected]")
 
-	schema, err := driver.DumpSchema(db)
+	if options.estimateOnly {
+		return printRowEstimates(ctx, driver, sqlDB)
+	}
+
+	out, checksum, err := openDumpOutput(options.outputFile, options.appendOutput)
+	if err != nil {
+		return err
+	}
+	defer out.abort()
+	// The checksum is computed while writing, so the file is not read back.
+	output := io.MultiWriter(out, checksum)
+
+	// The sections are written as they are dumped, the data table by table. What was produced
+	// before an interruption is kept. On failure, a new file is discarded, so the previous dump
+	// stays in place; output written in place is marked as partial instead.
+	stats, err := db.WriteDump(ctx, driver, sqlDB, output, db.DumpRequest{
+		Parts:            options.parts,
+		Table:            options.table,
+		WithDependencies: options.withDeps,
+		SkipData:         options.skipDataTables,
+		Dialect:          options.dialect,
+	})
+	if err != nil && !isInterrupted(err) {
+		reportDumpSummary(newDumpSummary(stats), options.summaryJSON)
+		if out.inPlace() {
+			io.WriteString(output, dumpFailedMarker)
+		}
+		out.abort()
+		return err
+	}
+	// interrupted holds the error of a dump cut short by Ctrl+C.
+	interrupted := err
+	path := options.outputFile
+	if interrupted != nil {
+		if _, err := io.WriteString(output, dumpIncompleteMarker); err != nil {
+			return apperrors.New(apperrors.ErrFileWrite, "failed to write dump file", err)
+		}
+		// A partial dump does not replace a complete one: it is kept next to it.
+		if !out.inPlace() {
+			path = partialPath(path)
+		}
+	}
+	if err := out.commit(path); err != nil {
+		return err
+	}
+	if options.outputFile == stdoutOutput {
+		logProgress("[Dump written to standard output]")
+	} else {
+		logProgress("[Dump written to %s]", path)
+		if err := util.WriteChecksumFile(path, checksum.Sum()); err != nil {
+			return err
+		}
+	}
+
+	if err := reportDumpSummary(newDumpSummary(stats), options.summaryJSON); err != nil {
+		return err
+	}
+	if interrupted != nil {
+		msg := fmt.Sprintf("dump interrupted, partial output written to %s", path)
+		return apperrors.New(apperrors.ErrInterrupted, msg, interrupted)
+	}
+	return nil
+}
+
+// openDumpOutput opens where a dump is written, along with the checksum of the file. When
+// appending, the checksum covers the content already there.
+func openDumpOutput(path string, appendOutput bool) (*dumpOutput, *util.ChecksumWriter, error) {
+	checksum := util.NewChecksumWriter(io.Discard)
+	if path == stdoutOutput {
+		return &dumpOutput{Writer: os.Stdout}, checksum, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, nil, apperrors.New(apperrors.ErrFileWrite, "failed to create the output directory", err)
+	}
+	if appendOutput {
+		// The checksum covers the whole file, so the part already there is hashed first.
+		if err := hashFile(checksum, path); err != nil {
+			return nil, nil, err
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, apperrors.New(apperrors.ErrFileWrite, "failed to open (or create) the dump file", err)
+		}
+		return &dumpOutput{Writer: file, file: file}, checksum, nil
+	}
+	file, err := util.CreateAtomic(path)
 	if err != nil {
-		log.Fatalf("Failed to retrieve tables: %v", err)
+		return nil, nil, err
+	}
+	return &dumpOutput{Writer: file, atomic: file}, checksum, nil
+}
+
+// dumpOutput is where a dump goes. A new file is written through a util.AtomicFile, so it only
+// replaces the previous dump once complete. Standard output and appended files are written
+// in place.
+type dumpOutput struct {
+	io.Writer
+	atomic *util.AtomicFile // The new file, nil when written in place.
+	file   *os.File         // The appended file, nil otherwise.
+}
+
+// inPlace reports whether what is written cannot be taken back.
+func (o *dumpOutput) inPlace() bool {
+	return o.atomic == nil
+}
+
+// commit finishes the output. A new file is moved to path, which is its own path unless the
+// dump is kept elsewhere.
+func (o *dumpOutput) commit(path string) error {
+	switch {
+	case o.atomic != nil:
+		return o.atomic.CommitAs(path)
+	case o.file != nil:
+		if err := o.file.Close(); err != nil {
+			return apperrors.New(apperrors.ErrFileWrite, "failed to write dump file", err)
+		}
+	}
+	return nil
+}
+
+// abort stops writing, discarding a new file but leaving output written in place as it is.
+func (o *dumpOutput) abort() {
+	switch {
+	case o.atomic != nil:
+		o.atomic.Abort()
+	case o.file != nil:
+		o.file.Close()
+	}
+}
+
+// partialPath returns where an interrupted dump meant for output is kept.
+func partialPath(output string) string {
+	return output + ".partial"
+}
+
+// expandOutputPath replaces the {db}, {date} and {time} placeholders of an --output path with the
+// database name and the current date (2006-01-02) and time (150405). The name is read from the
+// connection string or, when it names none, asked from the server, so a connection is only opened
+// when {db} is used without a database in the connection string.
+func expandOutputPath(ctx context.Context, path, dbType, connStr string, connectTimeout time.Duration, now time.Time) (string, error) {
+	if strings.Contains(path, "{db}") {
+		name, err := databaseName(ctx, dbType, connStr, connectTimeout)
+		if err != nil {
+			return "", err
+		}
+		// Keep the name usable as part of a file name whatever characters it holds.
+		name = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.' {
+				return r
+			}
+			return '_'
+		}, name)
+		path = strings.ReplaceAll(path, "{db}", name)
 	}
-	dump.WriteString(schema + "\nGO;\n\n")
+	path = strings.ReplaceAll(path, "{date}", now.Format("2006-01-02"))
+	return strings.ReplaceAll(path, "{time}", now.Format("150405")), nil
+}
 
-	data, err := driver.DumpData(db, options.skipDataTables)
+// databaseName returns the database a connection string names, connecting to ask the server
+// when the string leaves it to the login's default database.
+func databaseName(ctx context.Context, dbType, connStr string, connectTimeout time.Duration) (string, error) {
+	if info, err := db.ParseConnString(connStr); err == nil && info.Database != "" {
+		return info.Database, nil
+	}
+	driver, err := db.GetDriver(dbType, db.Config{ConnectTimeout: connectTimeout})
+	if err != nil {
+		return "", err
+	}
+	sqlDB, err := driver.Connect(ctx, connStr)
 	if err != nil {
-		log.Fatalf("Failed to retrieve tables: %v", err)
+		return "", fmt.Errorf("failed to connect to database: %w", err)
 	}
-	dump.WriteString(data + "\nGO;\n\n")
+	defer sqlDB.Close()
+	return driver.DatabaseName(ctx, sqlDB)
+}
 
-	constraints, err := driver.DumpConstraints(db)
+// sessionSettings returns the SET statements, other than SET IDENTITY_INSERT, found on their own
+// line in the file at path, so a dump appended to it does not repeat them. A missing file has none.
+func sessionSettings(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
 	if err != nil {
-		log.Fatalf("Failed to retrieve tables: %v", err)
+		return nil, apperrors.New(apperrors.ErrFileRead, "failed to open the dump to append to", err)
+	}
+	defer file.Close()
+
+	var settings []string
+	reader := bufio.NewReader(file)
+	for {
+		// Lines are read whole, as the values of a single row can be longer than a Scanner's buffer.
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "SET ") && !strings.HasPrefix(line, "SET IDENTITY_INSERT ") && !slices.Contains(settings, line) {
+			settings = append(settings, line)
+		}
+		if errors.Is(err, io.EOF) {
+			return settings, nil
+		}
+		if err != nil {
+			return nil, apperrors.New(apperrors.ErrFileRead, "failed to read the dump to append to", err)
+		}
 	}
-	dump.WriteString(constraints + "\nGO;\n\n")
+}
 
-	file, err := os.OpenFile(options.outputFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+// hashFile feeds the content of the file at path, if it exists, to checksum.
+func hashFile(checksum io.Writer, path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
-		log.Fatalf("Failed to open (or create) schema dump file: %v", err)
+		return apperrors.New(apperrors.ErrFileRead, "failed to open the dump to append to", err)
 	}
 	defer file.Close()
+	if _, err := io.Copy(checksum, bufio.NewReader(file)); err != nil {
+		return apperrors.New(apperrors.ErrFileRead, "failed to read the dump to append to", err)
+	}
+	return nil
+}
+
+// stdoutOutput is the --output value writing the dump to standard output.
+const stdoutOutput = "-"
 
-	_, err = file.Write([]byte(dump.String()))
+// dumpIncompleteMarker closes a dump that was interrupted before all phases ran.
+const dumpIncompleteMarker = "-- DUMP INCOMPLETE: interrupted before completion, do not use as a full backup.\n"
+
+// dumpFailedMarker closes a dump whose phase failed after part of it was written.
+const dumpFailedMarker = "-- DUMP FAILED: an error stopped the dump, do not use as a full backup.\n"
+
+// isInterrupted reports whether err comes from the user canceling the dump.
+func isInterrupted(err error) bool {
+	return apperrors.HasCode(err, apperrors.ErrInterrupted) || errors.Is(err, context.Canceled)
+}
+
+// dumpSummary is the end-of-dump report, printed and optionally saved as JSON.
+type dumpSummary struct {
+	Tables         int      `json:"tables"`
+	Rows           int64    `json:"rows"`
+	Bytes          int64    `json:"bytes"`
+	ElapsedSeconds float64  `json:"elapsed_seconds"`
+	SkippedTables  []string `json:"skipped_tables"`
+	FailedTables   []string `json:"failed_tables"`
+}
+
+func newDumpSummary(stats db.DumpStats) dumpSummary {
+	return dumpSummary{
+		Tables:         stats.Tables,
+		Rows:           stats.Rows,
+		Bytes:          stats.Bytes,
+		ElapsedSeconds: stats.Duration.Seconds(),
+		SkippedTables:  stats.SkippedTables,
+		FailedTables:   stats.FailedTables,
+	}
+}
+
+// reportDumpSummary prints the summary and, when jsonPath is set, writes it there as JSON.
+func reportDumpSummary(summary dumpSummary, jsonPath string) error {
+	out := util.ProgressOutput()
+	fmt.Fprintln(out, "Dump summary:")
+	fmt.Fprintln(out, " - Tables:", summary.Tables)
+	fmt.Fprintln(out, " - Rows:", summary.Rows)
+	fmt.Fprintln(out, " - Bytes Written:", summary.Bytes)
+	fmt.Fprintln(out, " - Elapsed:", time.Duration(summary.ElapsedSeconds*float64(time.Second)).Round(time.Millisecond))
+	fmt.Fprintln(out, " - Skipped Tables:", summary.SkippedTables)
+	fmt.Fprintln(out, " - Failed Tables:", summary.FailedTables)
+
+	if jsonPath == "" {
+		return nil
+	}
+	content, err := json.MarshalIndent(summary, "", "  ")
 	if err != nil {
-		log.Fatalf("Failed to write dump file: %v", err)
+		return apperrors.New(apperrors.ErrFileWrite, "failed to encode dump summary", err)
 	}
-	log.Printf("[Dump written to %s]", options.outputFile)
+	return util.ToFile(jsonPath, func(w io.Writer) error {
+		if _, err := w.Write(content); err != nil {
+			return apperrors.New(apperrors.ErrFileWrite, "failed to write dump summary", err)
+		}
+		return nil
+	})
+}
 
-	return nil
+// printRowEstimates prints the estimated row count per table and in total.
+func printRowEstimates(ctx context.Context, driver db.DatabaseDriver, sqlDB *sql.DB) error {
+	estimates, err := driver.EstimateRows(ctx, sqlDB)
+	if err != nil {
+		return err
+	}
+
+	tables := make([]db.TableName, 0, len(estimates))
+	for table := range estimates {
+		tables = append(tables, table)
+	}
+	slices.Sort(tables)
+
+	var total int64
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, table := range tables {
+		fmt.Fprintf(w, "%s\t%d\t\n", table, estimates[table])
+		total += estimates[table]
+	}
+	fmt.Fprintf(w, "Total (%d tables)\t%d\t\n", len(tables), total)
+	return w.Flush()
 }
 
 type dumpOptions struct {
-	connStr, dbType, include, outputFile string
-	skipTables, skipDataTables           []string
+	connStr, dbType, include, outputFile, table string
+	includeSchemas, excludeSchemas              []string
+	includeTables, skipTables, skipDataTables   util.TablePatterns
+	retryErrors                                 []int32
+	maxRetries, concurrency                     int
+	maxOpenConns, maxIdleConns, limit           int
+	sample, sampleMaxRows                       int
+	batchSize                                   int
+	retryBackoff, connLifetime, connectTimeout  time.Duration
+	parts                                       db.DumpParts
+	summaryJSON                                 string
+	where                                       map[db.TableName]string
+	mask                                        db.MaskRules
+	withDeps, estimateOnly, noIdentity          bool
+	dropExisting, ifNotExists, descriptions     bool
+	rowCounts, xactAbort, dataTx                bool
+	fkNoCheck, fkRecheck, naturalOrder          bool
+	disableFKs                                  bool
+	dialect                                     db.Dialect
+	resume, appendOutput                        bool
 }
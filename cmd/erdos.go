@@ -2,7 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/algermosen/go-erdos/internal/db"
+	"github.com/algermosen/go-erdos/util"
 	"github.com/spf13/cobra"
 )
 
@@ -18,13 +24,34 @@ Currently Supported Databases:
 - MSSQL
 - SQLite
 `,
+	Version: versionString(),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		util.SetQuiet(quiet)
+
+		// Reject unknown database types before any command starts connecting.
+		dbType, _ := cmd.Flags().GetString("dbtype")
+		if !slices.Contains(db.SupportedDrivers(), strings.ToLower(dbType)) {
+			cmd.SilenceUsage = true
+			msg := fmt.Sprintf("unsupported --dbtype '%s' (supported: %s)", dbType, strings.Join(db.SupportedDrivers(), ", "))
+			return apperrors.New(apperrors.ErrUnsupportedDatabase, msg, nil)
+		}
+		return nil
+	},
+	// Execute prints the error, so cobra does not print it a second time.
+	SilenceErrors: true,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("Welcome to Erdos! Use --help to see available commands.")
 	},
 }
 
 func init() {
-	// Add global flags here if needed in the future
-	rootCmd.PersistentFlags().String("dbtype", "mssql", "Type of the database (mssql, mysql, postgres, sqlite) (default: mssql)")
-	rootCmd.PersistentFlags().String("conn", "", "Database connection string")
+	rootCmd.SetVersionTemplate("erdos {{.Version}}\n")
+
+	// Global flags, available to every command. rootCmd is only defined here.
+	rootCmd.PersistentFlags().String("dbtype", "mssql", fmt.Sprintf("Type of the database (options: %s); inferred from the connection string when not given", strings.Join(db.SupportedDrivers(), ", ")))
+	rootCmd.PersistentFlags().String("conn", "", "Database connection string (or set ERDOS_CONN, or use --conn-file)")
+	rootCmd.PersistentFlags().String("conn-file", "", "File holding the database connection string, keeping it out of the shell history")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Only print errors and the final result, without progress or parameter echo")
+	rootCmd.PersistentFlags().Duration("connect-timeout", 10*time.Second, "How long to wait for the database server to answer when connecting (0: no limit)")
 }
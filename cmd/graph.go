@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/algermosen/go-erdos/internal/db"
+	"github.com/algermosen/go-erdos/util"
+	"github.com/spf13/cobra"
+)
+
+// graphCmd represents the graph command
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Prints the table dependency graph",
+	Long: `This command prints the foreign key dependencies between tables, so the migration
+order can be reviewed and cycles spotted. It only reads the database's metadata.
+
+Formats:
+- "dot" (default): Graphviz digraph, edges point from the referencing table to the referenced one.
+  Render it with e.g. erdos graph --conn ... | dot -Tsvg -o graph.svg
+- "json": Object mapping every table to the tables it references.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Retrieve flag values
+		connFlag, _ := cmd.Flags().GetString("conn")
+		connFile, _ := cmd.Flags().GetString("conn-file")
+		dbType, _ := cmd.Flags().GetString("dbtype")
+		connectTimeout, _ := cmd.Flags().GetDuration("connect-timeout")
+		format, _ := cmd.Flags().GetString("format")
+
+		// Validate required parameters
+		connStr, err := util.ResolveConnString(connFlag, connFile)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		format = strings.ToLower(format)
+		if format != "dot" && format != "json" {
+			msg := fmt.Sprintf("unsupported --format option '%s' (options: dot, json)", format)
+			appLogger.Error(apperrors.New(apperrors.ErrUnsupportedOption, msg, nil))
+			os.Exit(1)
+		}
+
+		driver, err := db.GetDriver(dbType, db.Config{ConnectTimeout: connectTimeout})
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		sqlDB, err := driver.Connect(cmd.Context(), connStr)
+		if err != nil {
+			appLogger.Error(fmt.Errorf("failed to connect to source database: %w", err))
+			os.Exit(1)
+		}
+		defer sqlDB.Close()
+
+		deps, err := driver.Dependencies(cmd.Context(), sqlDB)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+
+		if format == "json" {
+			err = deps.WriteJSON(os.Stdout)
+		} else {
+			err = deps.WriteDOT(os.Stdout)
+		}
+		if err != nil {
+			appLogger.Error(apperrors.New(apperrors.ErrFileWrite, "failed to write dependency graph", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+
+	// Define flags
+	graphCmd.Flags().String("format", "dot", "Output format (options: dot, json)")
+}
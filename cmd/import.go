@@ -2,9 +2,10 @@ package cmd
 
 import (
 	"fmt"
-	"log"
-	"strings"
+	"os"
 
+	"github.com/algermosen/go-erdos/internal/db"
+	"github.com/algermosen/go-erdos/util"
 	"github.com/spf13/cobra"
 )
 
@@ -15,44 +16,37 @@ var importCmd = &cobra.Command{
 	Long: `This command allows importing a database schema and/or data from a file or another database.
 Supported database types: PostgreSQL, SQLite, MSSQL.
 
-If the --db flag is not provided, the application will attempt to infer the database type. 
-If that is not possible, the default will be SQLite.`,
+If the --dbtype flag is not provided, the database type is inferred from the connection string.
+If that is not possible, the --dbtype default (mssql) is used.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Retrieve flag values
-		connStr, _ := cmd.Flags().GetString("conn")
-		dbType, _ := cmd.Flags().GetString("db")
+		connFlag, _ := cmd.Flags().GetString("conn")
+		connFile, _ := cmd.Flags().GetString("conn-file")
 		filePath, _ := cmd.Flags().GetString("file")
 
 		// Validate required parameters
-		if connStr == "" {
-			log.Fatal("Error: --conn flag is required")
+		connStr, err := util.ResolveConnString(connFlag, connFile)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
 		}
 
-		// Try to infer database type if not provided
-		if dbType == "" {
-			dbType = inferDBType(connStr)
-			if dbType == "" {
-				fmt.Println("Warning: Could not infer database type. Defaulting to SQLite.")
-				dbType = "sqlite"
-			}
-		}
+		// Infer the database type from the connection string if not provided.
+		dbType := resolveDBType(cmd, connStr)
 
-		fmt.Println("Starting database import with the following parameters:")
-		fmt.Println(" - Connection String:", connStr)
-		fmt.Println(" - Database Type:", dbType)
-		fmt.Println(" - File Path:", filePath)
+		if !util.IsQuiet() {
+			fmt.Println("Starting database import with the following parameters:")
+			fmt.Println(" - Connection String:", db.RedactConnString(connStr))
+			fmt.Println(" - Database Type:", dbType)
+			fmt.Println(" - File Path:", filePath)
+		}
 
-		// Call a handler function based on the selected database
-		switch dbType {
-		case "postgres":
-			importPostgres(connStr, filePath)
-		case "sqlite":
-			importSQLite(connStr, filePath)
-		case "mssql":
-			importMSSQL(connStr, filePath)
-		default:
-			log.Fatalf("Error: Unsupported database type '%s'", dbType)
+		driver, err := db.GetDriver(dbType, db.Config{})
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
 		}
+		importDatabase(driver, connStr, filePath)
 	},
 }
 
@@ -60,40 +54,11 @@ func init() {
 	rootCmd.AddCommand(importCmd)
 
 	// Define flags
-	importCmd.Flags().String("conn", "", "Connection string of the database (required)")
-	importCmd.Flags().String("db", "", "Type of database to use (options: postgres, sqlite, mssql). If not provided, the application will try to infer it (default: sqlite)")
 	importCmd.Flags().String("file", "", "Path to the SQL file or data source to import")
 }
 
-// inferDBType tries to determine the database type based on the connection string.
-func inferDBType(conn string) string {
-	lowerConn := strings.ToLower(conn)
-	switch {
-	case strings.Contains(lowerConn, "postgres") || strings.Contains(lowerConn, "5432"):
-		return "postgres"
-	case strings.Contains(lowerConn, "mssql") || strings.Contains(lowerConn, "1433"):
-		return "mssql"
-	case strings.Contains(lowerConn, "sqlite") || strings.Contains(lowerConn, ".db"):
-		return "sqlite"
-	default:
-		return ""
-	}
-}
-
-// Placeholder function for PostgreSQL import
-func importPostgres(connStr, filePath string) {
-	log.Println("Importing into PostgreSQL database...")
-	// Implement actual PostgreSQL import logic
-}
-
-// Placeholder function for SQLite import
-func importSQLite(connStr, filePath string) {
-	log.Println("Importing into SQLite database...")
-	// Implement actual SQLite import logic
-}
-
-// Placeholder function for MSSQL import
-func importMSSQL(connStr, filePath string) {
-	log.Println("Importing into MSSQL database...")
-	// Implement actual MSSQL import logic
+// Placeholder function for database import
+func importDatabase(driver db.DatabaseDriver, connStr, filePath string) {
+	logProgress("Importing into database...")
+	// Implement actual import logic on top of the driver
 }
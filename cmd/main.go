@@ -1,18 +1,33 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/algermosen/go-erdos/internal/db"
 	"github.com/algermosen/go-erdos/internal/logger"
+	"github.com/algermosen/go-erdos/util"
+	"github.com/spf13/cobra"
 )
 
 var appLogger logger.Logger
 
-// Execute runs the root command
+// exitInterrupted is the exit code used when a command is stopped by SIGINT (128 + SIGINT, as shells do).
+const exitInterrupted = 130
+
+// Execute runs the root command.
+// The context handed to the commands is canceled on SIGINT/SIGTERM.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println("Error:", err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
 }
@@ -20,3 +35,46 @@ func Execute() {
 func SetLogger(l logger.Logger) {
 	appLogger = l
 }
+
+// resolveDBType returns the --dbtype value, or when it was not given, the database type inferred
+// from the connection string, falling back to the --dbtype default.
+func resolveDBType(cmd *cobra.Command, connStr string) string {
+	dbType, _ := cmd.Flags().GetString("dbtype")
+	if cmd.Flags().Changed("dbtype") {
+		return dbType
+	}
+	if inferred := db.InferDriver(connStr); inferred != "" {
+		return inferred
+	}
+	return dbType
+}
+
+// logProgress logs a progress step, unless --quiet was given.
+func logProgress(format string, args ...any) {
+	if !util.IsQuiet() {
+		log.Printf(format, args...)
+	}
+}
+
+// describeTarget names the database a connection string points to for a confirmation prompt,
+// e.g. "database Shop on db01", without the credentials it holds.
+func describeTarget(connStr string) string {
+	info, err := db.ParseConnString(connStr)
+	if err != nil || info.Host == "" {
+		return "the target database"
+	}
+	server := info.Host
+	if info.Instance != "" {
+		server += `\` + info.Instance
+	}
+	if info.Database == "" {
+		return "the default database on " + server
+	}
+	return fmt.Sprintf("database %s on %s", info.Database, server)
+}
+
+// errUnsupported is the error of a command whose driver lacks the optional capability it needs.
+func errUnsupported(dbType, capability string) error {
+	msg := fmt.Sprintf("the %s driver does not support %s", dbType, capability)
+	return apperrors.New(apperrors.ErrUnsupportedDatabase, msg, nil)
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/algermosen/go-erdos/internal/db"
+	"github.com/algermosen/go-erdos/util"
+)
+
+// dumpManifest records which tables a resumable dump has fully dumped. The data section of
+// each of them is kept in its own file of the parts directory until the dump completes.
+type dumpManifest struct {
+	Output   string          `json:"output"`
+	Complete bool            `json:"complete"`
+	Tables   []manifestTable `json:"tables"`
+
+	mu   sync.Mutex
+	path string
+	next int // Number of the next part file.
+}
+
+// manifestTable is a fully dumped table, with the checksum of its part file.
+type manifestTable struct {
+	Table    db.TableName `json:"table"`
+	Rows     int64        `json:"rows"`
+	Checksum string       `json:"sha256"`
+	File     string       `json:"file"`
+}
+
+// manifestPath returns the path of the manifest of a dump written to output.
+func manifestPath(output string) string {
+	return output + ".manifest.json"
+}
+
+// partsDir returns the directory holding the per-table parts of a dump written to output.
+func partsDir(output string) string {
+	return output + ".parts"
+}
+
+// loadDumpManifest reads the manifest at path.
+func loadDumpManifest(path string) (*dumpManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, apperrors.New(apperrors.ErrFileRead, "failed to read dump manifest", err)
+	}
+	manifest := &dumpManifest{path: path}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		msg := fmt.Sprintf("invalid dump manifest %s", path)
+		return nil, apperrors.New(apperrors.ErrInvalidInput, msg, err)
+	}
+	return manifest, nil
+}
+
+// openDumpManifest starts the manifest of a dump written to output. The tables an unfinished
+// earlier run recorded are returned, except those whose part is missing or does not match its
+// checksum, which are dumped again. The progress of a finished run is discarded.
+func openDumpManifest(output string) (*dumpManifest, map[db.TableName]db.TableDump, error) {
+	path := manifestPath(output)
+	resumed := make(map[db.TableName]db.TableDump)
+	manifest := &dumpManifest{Output: output, Tables: []manifestTable{}, path: path}
+
+	previous, err := loadDumpManifest(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		// Nothing to resume yet: this is the first run.
+	case err != nil:
+		return nil, nil, err
+	case !previous.Complete:
+		// New parts are numbered after all the earlier ones, so no reused part is overwritten.
+		manifest.next = len(previous.Tables)
+		for _, entry := range previous.Tables {
+			data, err := os.ReadFile(filepath.Join(partsDir(output), entry.File))
+			if err != nil {
+				continue
+			}
+			checksum := util.NewChecksumWriter(io.Discard)
+			checksum.Write(data)
+			if checksum.Sum() != entry.Checksum {
+				continue
+			}
+			resumed[entry.Table] = db.TableDump{Data: string(data), Rows: entry.Rows}
+			manifest.Tables = append(manifest.Tables, entry)
+		}
+	}
+	if len(resumed) == 0 {
+		if err := os.RemoveAll(partsDir(output)); err != nil {
+			return nil, nil, apperrors.New(apperrors.ErrFileWrite, "failed to clear the dump parts", err)
+		}
+	}
+	if err := os.MkdirAll(partsDir(output), 0755); err != nil {
+		return nil, nil, apperrors.New(apperrors.ErrFileWrite, "failed to create the dump parts directory", err)
+	}
+	return manifest, resumed, manifest.save()
+}
+
+// record saves the data section of a fully dumped table and adds it to the manifest.
+// It is safe for concurrent use.
+func (m *dumpManifest) record(table db.TableName, dump db.TableDump) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.next++
+	name := fmt.Sprintf("%05d.sql", m.next)
+	file, err := os.Create(filepath.Join(partsDir(m.Output), name))
+	if err != nil {
+		return apperrors.New(apperrors.ErrFileWrite, "failed to create dump part", err)
+	}
+	defer file.Close()
+	checksum := util.NewChecksumWriter(file)
+	if _, err := checksum.Write([]byte(dump.Data)); err != nil {
+		return apperrors.New(apperrors.ErrFileWrite, "failed to write dump part", err)
+	}
+	if err := file.Sync(); err != nil {
+		return apperrors.New(apperrors.ErrFileWrite, "failed to write dump part", err)
+	}
+
+	m.Tables = append(m.Tables, manifestTable{Table: table, Rows: dump.Rows, Checksum: checksum.Sum(), File: name})
+	return m.save()
+}
+
+// finish marks the dump as complete and removes the parts, which the output file now holds.
+func (m *dumpManifest) finish() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Complete = true
+	if err := m.save(); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(partsDir(m.Output)); err != nil {
+		return apperrors.New(apperrors.ErrFileWrite, "failed to remove the dump parts", err)
+	}
+	return nil
+}
+
+// save writes the manifest through a temporary file, so a crash never leaves it half written.
+func (m *dumpManifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return apperrors.New(apperrors.ErrFileWrite, "failed to encode dump manifest", err)
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return apperrors.New(apperrors.ErrFileWrite, "failed to write dump manifest", err)
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		return apperrors.New(apperrors.ErrFileWrite, "failed to write dump manifest", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/algermosen/go-erdos/internal/db"
+	"github.com/algermosen/go-erdos/util"
+	"github.com/spf13/cobra"
+)
+
+// migrateDiffCmd represents the migrate-diff command
+var migrateDiffCmd = &cobra.Command{
+	Use:   "migrate-diff",
+	Short: "Generates the ALTER script bringing a database's schema in line with another one",
+	Long: `This command compares the schemas of the source and target databases, like diff, and
+writes the statements that bring the target in line with the source. Neither database
+is changed: review the script, then run it against the target (e.g. with erdos query).
+
+Statements come in a safe order: CREATE TABLE for the missing tables, ALTER TABLE ADD
+for the missing columns, and ALTER TABLE ALTER COLUMN for the columns whose type or
+nullability differ. Changes of IDENTITY cannot be made with ALTER and are only reported.
+Constraints are not migrated.
+
+Dropping the columns and tables that only the target has loses data, so those statements
+are only written with --allow-destructive; otherwise they are listed as comments.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Retrieve flag values
+		source, _ := cmd.Flags().GetString("source")
+		target, _ := cmd.Flags().GetString("target")
+		dbType, _ := cmd.Flags().GetString("dbtype")
+		outputFile, _ := cmd.Flags().GetString("output")
+		allowDestructive, _ := cmd.Flags().GetBool("allow-destructive")
+		connectTimeout, _ := cmd.Flags().GetDuration("connect-timeout")
+
+		// Validate required parameters
+		if util.IsEmpty(source) || util.IsEmpty(target) {
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "both --source and --target flags are required", nil))
+			os.Exit(1)
+		}
+
+		driver, err := db.GetDriver(dbType, db.Config{ConnectTimeout: connectTimeout})
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		differ, ok := driver.(db.Differ)
+		if !ok {
+			appLogger.Error(errUnsupported(dbType, "schema diffs"))
+			os.Exit(1)
+		}
+
+		diff, err := diffDatabases(cmd.Context(), driver, dbType, source, target, false)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		script, err := differ.MigrationScript(diff, allowDestructive)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+
+		if outputFile == "" {
+			fmt.Print(script)
+			return
+		}
+		err = util.ToFile(outputFile, func(w io.Writer) error {
+			if _, err := io.WriteString(w, script); err != nil {
+				return apperrors.New(apperrors.ErrFileWrite, "failed to write migration script", err)
+			}
+			return nil
+		})
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		logProgress("[Migration script written to %s]", outputFile)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateDiffCmd)
+
+	// Define flags
+	migrateDiffCmd.Flags().String("source", "", "Connection string of the database whose schema is the reference (required)")
+	migrateDiffCmd.Flags().String("target", "", "Connection string of the database to migrate (required)")
+	migrateDiffCmd.Flags().String("output", "", "File to save the migration script (default: standard output)")
+	migrateDiffCmd.Flags().Bool("allow-destructive", false, "Also drop the columns and tables that only the target has")
+}
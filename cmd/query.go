@@ -2,13 +2,17 @@ package cmd
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"log"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/algermosen/go-erdos/internal/apperrors"
 	"github.com/algermosen/go-erdos/internal/db"
+	"github.com/algermosen/go-erdos/util"
 	"github.com/spf13/cobra"
 )
 
@@ -16,79 +20,410 @@ import (
 var queryCmd = &cobra.Command{
 	Use:   "query",
 	Short: "Executes a SQL query from a file against a database",
-	Long:  "Executes a SQL query from a file against a specified database. Currently supports MSSQL.",
+	Long: `Executes a SQL query from a file against a specified database, using the driver
+registered for --dbtype. MSSQL scripts are split into batches on GO lines; scripts for
+other databases are split into statements on semicolons.
+
+By default every batch is executed and committed on its own. With --transaction all
+batches run inside a single transaction that is rolled back if any of them fails.
+Each batch is still sent separately, so statements that must start a batch (such as
+CREATE PROCEDURE or CREATE VIEW) keep working. Statements SQL Server refuses to run
+inside a transaction (ALTER/CREATE/DROP DATABASE, BACKUP, RESTORE, full-text catalog
+changes, ...) are rejected before anything is executed.
+
+Each statement may run for --statement-timeout (0: no limit). Without a transaction,
+--continue-on-error keeps going past failing statements and reports them all at the end.
+
+To check how a script is split before running it, --print-plan lists the statements
+with their line ranges, and --dry-run connects (validating the credentials) and prints
+every statement without executing anything.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Retrieve flag values.
-		connStr, _ := cmd.Flags().GetString("conn")
+		connFlag, _ := cmd.Flags().GetString("conn")
+		connFile, _ := cmd.Flags().GetString("conn-file")
 		dbType, _ := cmd.Flags().GetString("dbtype")
 		queryFile, _ := cmd.Flags().GetString("query-file")
+		useTransaction, _ := cmd.Flags().GetBool("transaction")
+		connectTimeout, _ := cmd.Flags().GetDuration("connect-timeout")
+		statementTimeout, _ := cmd.Flags().GetDuration("statement-timeout")
+		continueOnError, _ := cmd.Flags().GetBool("continue-on-error")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		printPlan, _ := cmd.Flags().GetBool("print-plan")
 
 		// Validate required flags.
-		if connStr == "" {
-			log.Fatal("Error: --conn flag is required")
+		connStr, err := util.ResolveConnString(connFlag, connFile)
+		if err != nil && !printPlan {
+			appLogger.Error(err)
+			os.Exit(1)
 		}
+		dbType = resolveDBType(cmd, connStr)
 		if queryFile == "" {
-			log.Fatal("Error: --query-file flag is required")
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "the --query-file flag is required", nil))
+			os.Exit(1)
 		}
-
-		// For now, only support MSSQL.
-		if strings.ToLower(dbType) != "mssql" {
-			log.Fatalf("Currently, only MSSQL is supported. Provided: %s", dbType)
+		if continueOnError && useTransaction {
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "--continue-on-error cannot be combined with --transaction", nil))
+			os.Exit(1)
 		}
 
 		// Read the SQL query from the specified file.
 		queryData, err := os.ReadFile(queryFile)
 		if err != nil {
-			log.Fatalf("Failed to read query file: %v", err)
+			appLogger.Error(apperrors.New(apperrors.ErrFileRead, "failed to read query file", err))
+			os.Exit(1)
+		}
+		statements := splitScript(dbType, string(queryData))
+
+		if printPlan {
+			for i, lines := range statementLines(string(queryData), statements) {
+				fmt.Printf("%d\tlines %d-%d\t%s\n", i+1, lines[0], lines[1], statementSnippet(statements[i]))
+			}
+			return
 		}
-		statements := splitSQLStatements(string(queryData))
 
 		// Connect to the database.
-		driver := db.NewMSSQLDriver()
-		sqlDB, err := driver.Connect(connStr)
+		driver, err := db.GetDriver(dbType, db.Config{ConnectTimeout: connectTimeout})
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		sqlDB, err := driver.Connect(cmd.Context(), connStr)
 		if err != nil {
-			log.Fatalf("Failed to connect to database: %v", err)
+			appLogger.Error(fmt.Errorf("failed to connect to database: %w", err))
+			os.Exit(1)
 		}
 		defer sqlDB.Close()
-		log.Println("[Database connected]")
-		log.Println("")
+		logProgress("[Database connected]")
+		logProgress("")
+
+		if dryRun {
+			for i, stmt := range statements {
+				fmt.Printf("-- Statement %d/%d\n%s\n\n", i+1, len(statements), stmt)
+			}
+			return
+		}
+
+		if useTransaction {
+			if err := executeInTransaction(cmd.Context(), sqlDB, statements, statementTimeout); err != nil {
+				appLogger.Error(fmt.Errorf("transaction rolled back: %w", err))
+				os.Exit(1)
+			}
+			logProgress("[Transaction committed]")
+			return
+		}
 
 		// Execute the query.
+		var failures []string
 		for i, stmt := range statements {
 			stmt = strings.TrimSpace(stmt)
 			if stmt == "" {
 				continue
 			}
 
-			fmt.Print("\033[1A\033[K") // moves up and then deletes the line
-			fmt.Printf("Executing statement %d/%d\n", i+1, len(statements))
+			util.Progress("Executing statement %d/%d", i+1, len(statements))
 			// Use context with timeout for each statement.
-			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			ctx, cancel := withStatementTimeout(cmd.Context(), statementTimeout)
 			_, err = sqlDB.ExecContext(ctx, stmt)
 			cancel()
 			if err != nil {
-				log.Fatalf("Error executing statement %d: %v\nStatement: %s", i+1, err, stmt)
+				if !continueOnError || cmd.Context().Err() != nil {
+					appLogger.Error(fmt.Errorf("error executing statement %d: %w\nStatement: %s", i+1, err, stmt))
+					os.Exit(1)
+				}
+				failures = append(failures, fmt.Sprintf("statement %d (%s): %v", i+1, statementSnippet(stmt), err))
 			}
 		}
 
+		if len(failures) > 0 {
+			for _, failure := range failures {
+				appLogger.Error(failure)
+			}
+			appLogger.Error(fmt.Errorf("%d of %d statements failed", len(failures), len(statements)))
+			os.Exit(1)
+		}
+
 	},
 }
 
+// nonTransactionalBatch matches statements SQL Server does not allow inside a user transaction.
+var nonTransactionalBatch = regexp.MustCompile(`(?im)^\s*(ALTER\s+DATABASE|CREATE\s+DATABASE|DROP\s+DATABASE|BACKUP|RESTORE|RECONFIGURE|(CREATE|ALTER|DROP)\s+FULLTEXT\s+(CATALOG|INDEX))\b`)
+
+// executeInTransaction runs every statement inside a single transaction.
+// Each statement is sent as its own batch, and the transaction is rolled back on the first failure.
+func executeInTransaction(ctx context.Context, sqlDB *sql.DB, statements []string, timeout time.Duration) error {
+	for i, stmt := range statements {
+		if match := nonTransactionalBatch.FindString(stmt); match != "" {
+			msg := fmt.Sprintf("statement %d cannot run inside a transaction (%s)", i+1, strings.TrimSpace(match))
+			return apperrors.New(apperrors.ErrTransaction, msg, nil)
+		}
+	}
+
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return apperrors.New(apperrors.ErrTransaction, "failed to begin transaction", err)
+	}
+
+	for i, stmt := range statements {
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" {
+			continue
+		}
+
+		util.Progress("Executing statement %d/%d (in transaction)", i+1, len(statements))
+		stmtCtx, cancel := withStatementTimeout(ctx, timeout)
+		_, err := tx.ExecContext(stmtCtx, stmt)
+		cancel()
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				return apperrors.New(apperrors.ErrTransaction, "rollback failed", rbErr)
+			}
+			msg := fmt.Sprintf("error executing statement %d\nStatement: %s", i+1, stmt)
+			return apperrors.New(apperrors.ErrTransaction, msg, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return apperrors.New(apperrors.ErrTransaction, "failed to commit transaction", err)
+	}
+	return nil
+}
+
+// statementLines returns the first and last line (1-based) of every statement within the script.
+// A statement repeated with "GO n" reports the lines of its original text.
+func statementLines(sqlContent string, statements []string) [][2]int {
+	lines := make([][2]int, len(statements))
+	offset, last := 0, [2]int{1, 1}
+	for i, stmt := range statements {
+		if idx := strings.Index(sqlContent[offset:], stmt); idx >= 0 {
+			start := strings.Count(sqlContent[:offset+idx], "\n") + 1
+			last = [2]int{start, start + strings.Count(stmt, "\n")}
+			offset += idx + len(stmt)
+		}
+		lines[i] = last
+	}
+	return lines
+}
+
+// statementSnippet shortens a statement to its first line, capped at 80 characters, for error reports.
+func statementSnippet(stmt string) string {
+	snippet, _, multiline := strings.Cut(stmt, "\n")
+	snippet = strings.TrimSpace(snippet)
+	if runes := []rune(snippet); len(runes) > 80 {
+		snippet, multiline = string(runes[:80]), true
+	}
+	if multiline {
+		snippet += "..."
+	}
+	return snippet
+}
+
+// withStatementTimeout derives the context of a single statement. A zero timeout means no limit.
+func withStatementTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// splitScript splits a script into the statements to execute, following the dialect of dbType:
+// MSSQL scripts are split into batches on GO lines, other databases on semicolons.
+func splitScript(dbType, sqlContent string) []string {
+	switch strings.ToLower(dbType) {
+	case "mssql":
+		return splitSQLStatements(sqlContent)
+	case "postgres", "postgresql":
+		return splitOnSemicolons(sqlContent, true)
+	default:
+		return splitOnSemicolons(sqlContent, false)
+	}
+}
+
+// splitOnSemicolons splits a script on semicolons that end a statement, ignoring those inside
+// string literals, quoted identifiers and comments. With dollarQuotes, PostgreSQL $tag$ ... $tag$
+// strings (used for function bodies) are honored as well.
+func splitOnSemicolons(sqlContent string, dollarQuotes bool) []string {
+	var result []string
+	var stmt strings.Builder
+	flush := func() {
+		if trimmed := strings.TrimSpace(stmt.String()); trimmed != "" {
+			result = append(result, trimmed)
+		}
+		stmt.Reset()
+	}
+
+	var quote byte       // The closing character of the open literal (' " ` or ]), or 0.
+	var dollarTag string // The open $tag$ delimiter, or "".
+	blockComment := 0    // Nesting depth of /* */ comments.
+	lineComment := false
+	for i := 0; i < len(sqlContent); i++ {
+		c := sqlContent[i]
+		var next byte
+		if i+1 < len(sqlContent) {
+			next = sqlContent[i+1]
+		}
+
+		switch {
+		case lineComment:
+			if c == '\n' {
+				lineComment = false
+			}
+		case blockComment > 0:
+			if c == '*' && next == '/' {
+				blockComment--
+				stmt.WriteByte(c)
+				i++
+				c = next
+			} else if c == '/' && next == '*' {
+				blockComment++
+				stmt.WriteByte(c)
+				i++
+				c = next
+			}
+		case dollarTag != "":
+			if strings.HasPrefix(sqlContent[i:], dollarTag) {
+				stmt.WriteString(dollarTag[:len(dollarTag)-1])
+				i += len(dollarTag) - 1
+				c = '$'
+				dollarTag = ""
+			}
+		case quote != 0:
+			if c == quote {
+				// A doubled closing character is an escaped one.
+				if next == quote {
+					stmt.WriteByte(c)
+					i++
+				} else {
+					quote = 0
+				}
+			}
+		case c == '-' && next == '-':
+			lineComment = true
+		case c == '/' && next == '*':
+			blockComment++
+			stmt.WriteByte(c)
+			i++
+			c = next
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[' && !dollarQuotes:
+			quote = ']'
+		case c == '$' && dollarQuotes:
+			if tag := dollarQuoteTag.FindString(sqlContent[i:]); tag != "" {
+				dollarTag = tag
+				stmt.WriteString(tag[:len(tag)-1])
+				i += len(tag) - 1
+			}
+		case c == ';':
+			flush()
+			continue
+		}
+		stmt.WriteByte(c)
+	}
+	flush()
+	return result
+}
+
+// dollarQuoteTag matches the opening delimiter of a PostgreSQL dollar-quoted string, such as $$ or $body$.
+var dollarQuoteTag = regexp.MustCompile(`^\$(?:[A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+// batchSeparator matches a line holding only the GO batch separator, optionally followed by a repeat count.
+// The trailing semicolon is tolerated for dumps written by older versions.
+var batchSeparator = regexp.MustCompile(`(?i)^\s*GO(?:\s+(\d+))?\s*;?\s*(?:--.*)?$`)
+
+// splitSQLStatements splits a script into batches on GO lines, the same way sqlcmd does.
+// GO is only honored on its own line and never inside string literals, quoted identifiers or comments.
+// "GO n" repeats the preceding batch n times.
 func splitSQLStatements(sqlContent string) []string {
-	// A simple splitting by semicolon.
-	// Note: This approach may need improvements for complex SQL scripts.
-	statements := strings.Split(sqlContent, "GO;")
 	var result []string
-	for _, s := range statements {
-		trimmed := strings.TrimSpace(s)
-		if trimmed != "" {
+	var batch strings.Builder
+	var state sqlScanState
+
+	flush := func(repeat int) {
+		trimmed := strings.TrimSpace(batch.String())
+		batch.Reset()
+		if trimmed == "" {
+			return
+		}
+		for i := 0; i < repeat; i++ {
 			result = append(result, trimmed)
 		}
 	}
+
+	for _, line := range strings.Split(sqlContent, "\n") {
+		if state.isCode() {
+			if match := batchSeparator.FindStringSubmatch(line); match != nil {
+				repeat := 1
+				if match[1] != "" {
+					repeat, _ = strconv.Atoi(match[1])
+				}
+				flush(repeat)
+				continue
+			}
+		}
+		state.scanLine(line)
+		batch.WriteString(line)
+		batch.WriteString("\n")
+	}
+	flush(1)
 	return result
 }
 
+// sqlScanState tracks whether the scanner is inside a literal or comment that spans lines.
+type sqlScanState struct {
+	quote        byte // The closing character of the open literal (' " or ]), or 0.
+	blockComment int  // Nesting depth of /* */ comments; T-SQL allows nesting.
+}
+
+func (s *sqlScanState) isCode() bool {
+	return s.quote == 0 && s.blockComment == 0
+}
+
+// scanLine advances the state over a single line of SQL.
+func (s *sqlScanState) scanLine(line string) {
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		var next byte
+		if i+1 < len(line) {
+			next = line[i+1]
+		}
+
+		switch {
+		case s.blockComment > 0:
+			if c == '*' && next == '/' {
+				s.blockComment--
+				i++
+			} else if c == '/' && next == '*' {
+				s.blockComment++
+				i++
+			}
+		case s.quote != 0:
+			if c == s.quote {
+				// A doubled closing character is an escaped one.
+				if next == s.quote {
+					i++
+				} else {
+					s.quote = 0
+				}
+			}
+		case c == '-' && next == '-':
+			return // The rest of the line is a comment.
+		case c == '/' && next == '*':
+			s.blockComment++
+			i++
+		case c == '\'' || c == '"':
+			s.quote = c
+		case c == '[':
+			s.quote = ']'
+		}
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(queryCmd)
 	queryCmd.Flags().String("query-file", "", "Path to the file containing the SQL query to execute")
+	queryCmd.Flags().Bool("transaction", false, "Run all statements in a single transaction, rolling back on any failure")
+	queryCmd.Flags().Duration("statement-timeout", 2*time.Minute, "Maximum time a single statement may run (0: no limit)")
+	queryCmd.Flags().Bool("dry-run", false, "Connect, then print every parsed statement instead of executing it")
+	queryCmd.Flags().Bool("print-plan", false, "List the statement boundaries found in the file and exit, without connecting")
+	queryCmd.Flags().Bool("continue-on-error", false, "Keep executing after a failing statement and report all failures at the end")
 }
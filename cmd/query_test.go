@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitSQLStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "no separator",
+			script: "SELECT 1;\nSELECT 2;",
+			want:   []string{"SELECT 1;\nSELECT 2;"},
+		},
+		{
+			name:   "GO lines",
+			script: "SELECT 1;\nGO\nSELECT 2;\ngo\nSELECT 3;\n",
+			want:   []string{"SELECT 1;", "SELECT 2;", "SELECT 3;"},
+		},
+		{
+			name:   "GO with semicolon, spaces and comment",
+			script: "SELECT 1;\n  GO;  -- end of batch\nSELECT 2;",
+			want:   []string{"SELECT 1;", "SELECT 2;"},
+		},
+		{
+			name:   "repeat count",
+			script: "INSERT INTO T DEFAULT VALUES;\nGO 3\nSELECT 1;",
+			want:   []string{"INSERT INTO T DEFAULT VALUES;", "INSERT INTO T DEFAULT VALUES;", "INSERT INTO T DEFAULT VALUES;", "SELECT 1;"},
+		},
+		{
+			name:   "empty batches",
+			script: "GO\n\nGO\nSELECT 1;\nGO\nGO\n",
+			want:   []string{"SELECT 1;"},
+		},
+		{
+			name:   "GO within a line",
+			script: "SELECT 'a' AS GO;\nEXEC GO;",
+			want:   []string{"SELECT 'a' AS GO;\nEXEC GO;"},
+		},
+		{
+			name:   "GO in a string literal",
+			script: "INSERT INTO T VALUES ('first\nGO\nsecond');\nGO\nSELECT 1;",
+			want:   []string{"INSERT INTO T VALUES ('first\nGO\nsecond');", "SELECT 1;"},
+		},
+		{
+			name:   "escaped quote in a string literal",
+			script: "SELECT 'it''s\nGO\nfine';\nGO\nSELECT 1;",
+			want:   []string{"SELECT 'it''s\nGO\nfine';", "SELECT 1;"},
+		},
+		{
+			name:   "GO in a block comment",
+			script: "CREATE PROCEDURE p AS\n/* run it:\nGO\n*/\nSELECT 1;\nGO\nEXEC p;",
+			want:   []string{"CREATE PROCEDURE p AS\n/* run it:\nGO\n*/\nSELECT 1;", "EXEC p;"},
+		},
+		{
+			name:   "nested block comment",
+			script: "/* outer /* inner */\nGO\n*/\nSELECT 1;",
+			want:   []string{"/* outer /* inner */\nGO\n*/\nSELECT 1;"},
+		},
+		{
+			name:   "quote in a line comment",
+			script: "SELECT 1; -- don't\nGO\nSELECT 2;",
+			want:   []string{"SELECT 1; -- don't", "SELECT 2;"},
+		},
+		{
+			name:   "GO in a quoted identifier",
+			script: "SELECT 1 AS [a\nGO\nb];\nGO\nSELECT 2;",
+			want:   []string{"SELECT 1 AS [a\nGO\nb];", "SELECT 2;"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitSQLStatements(tt.script); !slices.Equal(got, tt.want) {
+				t.Errorf("splitSQLStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitOnSemicolons(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		dollarQuotes bool
+		want         []string
+	}{
+		{
+			name:   "statements",
+			script: "SELECT 1;\nSELECT 2;\n",
+			want:   []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name:   "missing trailing semicolon",
+			script: "SELECT 1;\nSELECT 2",
+			want:   []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name:   "empty statements",
+			script: ";;\nSELECT 1;;  ;\n",
+			want:   []string{"SELECT 1"},
+		},
+		{
+			name:   "semicolon in a string literal",
+			script: "INSERT INTO t VALUES ('a;b', 'it''s; fine');SELECT 2;",
+			want:   []string{"INSERT INTO t VALUES ('a;b', 'it''s; fine')", "SELECT 2"},
+		},
+		{
+			name:   "semicolon in quoted identifiers",
+			script: "SELECT \"a;b\", `c;d` FROM t;SELECT 2;",
+			want:   []string{"SELECT \"a;b\", `c;d` FROM t", "SELECT 2"},
+		},
+		{
+			name:   "semicolon in a bracketed identifier",
+			script: "SELECT [a;b] FROM t;SELECT 2;",
+			want:   []string{"SELECT [a;b] FROM t", "SELECT 2"},
+		},
+		{
+			name:   "semicolon in a line comment",
+			script: "SELECT 1; -- one; two\nSELECT 2;",
+			want:   []string{"SELECT 1", "-- one; two\nSELECT 2"},
+		},
+		{
+			name:   "semicolon in a block comment",
+			script: "SELECT /* a; b */ 1;SELECT 2;",
+			want:   []string{"SELECT /* a; b */ 1", "SELECT 2"},
+		},
+		{
+			name:   "nested block comment",
+			script: "SELECT /* outer /* inner; */ still; */ 1;SELECT 2;",
+			want:   []string{"SELECT /* outer /* inner; */ still; */ 1", "SELECT 2"},
+		},
+		{
+			name:         "dollar-quoted body",
+			script:       "CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql;SELECT f();",
+			dollarQuotes: true,
+			want:         []string{"CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql", "SELECT f()"},
+		},
+		{
+			name:         "tagged dollar quote holding $$",
+			script:       "DO $body$ BEGIN PERFORM '$$;'; END; $body$;SELECT 1;",
+			dollarQuotes: true,
+			want:         []string{"DO $body$ BEGIN PERFORM '$$;'; END; $body$", "SELECT 1"},
+		},
+		{
+			name:         "dollar in a PostgreSQL identifier",
+			script:       "SELECT a$1, [x; FROM t;SELECT 2;",
+			dollarQuotes: true,
+			want:         []string{"SELECT a$1, [x", "FROM t", "SELECT 2"},
+		},
+		{
+			name:   "no dollar quotes outside PostgreSQL",
+			script: "SELECT '$$';SELECT $$;SELECT 2;",
+			want:   []string{"SELECT '$$'", "SELECT $$", "SELECT 2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitOnSemicolons(tt.script, tt.dollarQuotes); !slices.Equal(got, tt.want) {
+				t.Errorf("splitOnSemicolons() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitScript(t *testing.T) {
+	const script = "SELECT 1;\nSELECT 2;\nGO\nSELECT $$a;b$$;"
+	tests := []struct {
+		dbType string
+		want   []string
+	}{
+		{"mssql", []string{"SELECT 1;\nSELECT 2;", "SELECT $$a;b$$;"}},
+		{"MSSQL", []string{"SELECT 1;\nSELECT 2;", "SELECT $$a;b$$;"}},
+		{"postgres", []string{"SELECT 1", "SELECT 2", "GO\nSELECT $$a;b$$"}},
+		{"postgresql", []string{"SELECT 1", "SELECT 2", "GO\nSELECT $$a;b$$"}},
+		{"mysql", []string{"SELECT 1", "SELECT 2", "GO\nSELECT $$a", "b$$"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dbType, func(t *testing.T) {
+			if got := splitScript(tt.dbType, script); !slices.Equal(got, tt.want) {
+				t.Errorf("splitScript(%q) = %q, want %q", tt.dbType, got, tt.want)
+			}
+		})
+	}
+}
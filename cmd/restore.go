@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/algermosen/go-erdos/internal/db"
+	"github.com/algermosen/go-erdos/util"
+	"github.com/spf13/cobra"
+)
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restores a native backup written by backup",
+	Long: `This command has the server restore a backup written by backup (or any full backup
+made with BACKUP DATABASE) with RESTORE DATABASE. The --from path is on the database
+server, not on the machine running erdos.
+
+The backup is restored as the database given to --database, which defaults to the one
+named in the connection string. Restoring over an existing database replaces all of its
+content, so it is refused unless --replace is given, and --replace asks for confirmation
+first; pass --yes to skip the prompt, which is required when standard input is not a
+terminal. The database must not be in use by other sessions while it is restored.
+
+The database files are restored to the paths recorded in the backup, so restoring a
+copy next to the original under another name fails on the same server.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Retrieve flag values
+		connFlag, _ := cmd.Flags().GetString("conn")
+		connFile, _ := cmd.Flags().GetString("conn-file")
+		connectTimeout, _ := cmd.Flags().GetDuration("connect-timeout")
+		from, _ := cmd.Flags().GetString("from")
+		database, _ := cmd.Flags().GetString("database")
+		replace, _ := cmd.Flags().GetBool("replace")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		// Validate required parameters
+		connStr, err := util.ResolveConnString(connFlag, connFile)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		if util.IsEmpty(from) {
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, "the --from flag is required", nil))
+			os.Exit(1)
+		}
+		if util.IsEmpty(database) {
+			if info, err := db.ParseConnString(connStr); err == nil {
+				database = info.Database
+			}
+		}
+		if util.IsEmpty(database) {
+			msg := "the connection string names no database, so the --database flag is required"
+			appLogger.Error(apperrors.New(apperrors.ErrInvalidInput, msg, nil))
+			os.Exit(1)
+		}
+
+		if replace {
+			if err := util.ConfirmModify("database "+database, yes); err != nil {
+				appLogger.Error(err)
+				os.Exit(1)
+			}
+		}
+
+		dbType := resolveDBType(cmd, connStr)
+		driver, err := db.GetDriver(dbType, db.Config{
+			ConnectTimeout: connectTimeout,
+			Progress:       util.NewTerminalProgress(),
+		})
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		backupper, ok := driver.(db.Backupper)
+		if !ok {
+			appLogger.Error(errUnsupported(dbType, "native backups"))
+			os.Exit(1)
+		}
+		sqlDB, err := driver.Connect(cmd.Context(), connStr)
+		if err != nil {
+			appLogger.Error(fmt.Errorf("failed to connect to database: %w", err))
+			os.Exit(1)
+		}
+		defer sqlDB.Close()
+		logProgress("[Database connected]")
+
+		if err := backupper.Restore(cmd.Context(), sqlDB, from, database, replace); err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		logProgress("[Database %s restored from %s]", database, from)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+
+	// Define flags
+	restoreCmd.Flags().String("from", "", "Path of the backup file, on the database server (required)")
+	restoreCmd.Flags().String("database", "", "Name of the restored database (default: the database of the connection string)")
+	restoreCmd.Flags().Bool("replace", false, "Overwrite the database if it already exists")
+	restoreCmd.Flags().BoolP("yes", "y", false, "Replace the database without asking for confirmation")
+}
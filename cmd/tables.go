@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/algermosen/go-erdos/internal/db"
+	"github.com/algermosen/go-erdos/util"
+	"github.com/spf13/cobra"
+)
+
+// tablesCmd represents the tables command
+var tablesCmd = &cobra.Command{
+	Use:   "tables",
+	Short: "Lists the tables of a database",
+	Long: `This command prints the schema-qualified name of every user table, sorted, e.g. to pick
+the tables to pass to dump --skip. With --estimates, each table is followed by its
+approximate row count, read from the table metadata rather than by counting.
+
+Formats:
+- "text" (default): One table per line.
+- "json": Array of {"table": ..., "rows": ...} objects; rows is only set with --estimates.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Retrieve flag values
+		connFlag, _ := cmd.Flags().GetString("conn")
+		connFile, _ := cmd.Flags().GetString("conn-file")
+		dbType, _ := cmd.Flags().GetString("dbtype")
+		connectTimeout, _ := cmd.Flags().GetDuration("connect-timeout")
+		format, _ := cmd.Flags().GetString("format")
+		withEstimates, _ := cmd.Flags().GetBool("estimates")
+
+		// Validate required parameters
+		connStr, err := util.ResolveConnString(connFlag, connFile)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		format = strings.ToLower(format)
+		if format != "text" && format != "json" {
+			msg := fmt.Sprintf("unsupported --format option '%s' (options: text, json)", format)
+			appLogger.Error(apperrors.New(apperrors.ErrUnsupportedOption, msg, nil))
+			os.Exit(1)
+		}
+
+		driver, err := db.GetDriver(dbType, db.Config{ConnectTimeout: connectTimeout})
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		sqlDB, err := driver.Connect(cmd.Context(), connStr)
+		if err != nil {
+			appLogger.Error(fmt.Errorf("failed to connect to source database: %w", err))
+			os.Exit(1)
+		}
+		defer sqlDB.Close()
+
+		tables, err := driver.Tables(cmd.Context(), sqlDB)
+		if err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		var estimates map[db.TableName]int64
+		if withEstimates {
+			if estimates, err = driver.EstimateRows(cmd.Context(), sqlDB); err != nil {
+				appLogger.Error(err)
+				os.Exit(1)
+			}
+		}
+
+		if format == "json" {
+			err = writeTablesJSON(tables, estimates)
+		} else {
+			err = writeTablesText(tables, estimates)
+		}
+		if err != nil {
+			appLogger.Error(apperrors.New(apperrors.ErrFileWrite, "failed to write table list", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tablesCmd)
+
+	// Define flags
+	tablesCmd.Flags().String("format", "text", "Output format (options: text, json)")
+	tablesCmd.Flags().Bool("estimates", false, "Also print the estimated row count of every table")
+}
+
+// writeTablesText prints one table per line, followed by its row estimate if there are estimates.
+func writeTablesText(tables []db.TableName, estimates map[db.TableName]int64) error {
+	if estimates == nil {
+		for _, table := range tables {
+			fmt.Println(table)
+		}
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, table := range tables {
+		fmt.Fprintf(w, "%s\t%d\t\n", table, estimates[table])
+	}
+	return w.Flush()
+}
+
+// writeTablesJSON prints the tables as a JSON array, with their row estimates if there are estimates.
+func writeTablesJSON(tables []db.TableName, estimates map[db.TableName]int64) error {
+	type tableEntry struct {
+		Table db.TableName `json:"table"`
+		Rows  *int64       `json:"rows,omitempty"`
+	}
+	entries := make([]tableEntry, 0, len(tables))
+	for _, table := range tables {
+		entry := tableEntry{Table: table}
+		if estimates != nil {
+			rows := estimates[table]
+			entry.Rows = &rows
+		}
+		entries = append(entries, entry)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/algermosen/go-erdos/util"
+	"github.com/spf13/cobra"
+)
+
+// verifyDumpCmd represents the verify-dump command
+var verifyDumpCmd = &cobra.Command{
+	Use:   "verify-dump <file>",
+	Short: "Checks a dump file against its checksum manifest",
+	Long: `This command recomputes the SHA-256 of a dump file and compares it with the manifest
+written next to it by dump (<file>.sha256), to detect truncated or modified dumps.
+The manifest uses the sha256sum format, so "sha256sum -c <file>.sha256" works as well.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := util.VerifyChecksumFile(args[0]); err != nil {
+			appLogger.Error(err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s: OK\n", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyDumpCmd)
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// Build information, injected at build time, e.g.
+//
+//	go build -ldflags "-X github.com/algermosen/go-erdos/cmd.version=v1.2.0 -X github.com/algermosen/go-erdos/cmd.commit=$(git rev-parse --short HEAD)"
+var (
+	version = "dev"
+	commit  = "unknown"
+	date    = "unknown"
+)
+
+// versionString describes the running build.
+func versionString() string {
+	return fmt.Sprintf("%s (commit %s, built %s)", version, commit, date)
+}
+
+// versionCmd represents the version command
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Prints the version of Erdos",
+	Long: `This command prints the version, git commit and build date of this build of Erdos,
+to be quoted in bug reports. erdos --version prints the same.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("erdos", versionString())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+}
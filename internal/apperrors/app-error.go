@@ -1,18 +1,97 @@
 package apperrors
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 type AppError struct {
 	Code    ErrCode
 	Message string
 	Err     error
+
+	// Table is the table the error is about, e.g. "[dbo].[Orders]", when there is one.
+	// Column is the column of that table the error is about, when there is one.
+	Table  string
+	Column string
 }
 
 func (e *AppError) Error() string {
+	message := e.Summary()
+	switch {
+	case e.Table != "" && e.Column != "":
+		return fmt.Sprintf("%s (table %s, column %s)", message, e.Table, e.Column)
+	case e.Table != "":
+		return fmt.Sprintf("%s (table %s)", message, e.Table)
+	case e.Column != "":
+		return fmt.Sprintf("%s (column %s)", message, e.Column)
+	}
+	return message
+}
+
+// Summary returns the error message without the table and column it is about, for output
+// that shows them apart, such as the log.
+func (e *AppError) Summary() string {
 	if e.Err != nil {
-		return fmt.Sprintf("%d | %s - %v", e.Code, e.Message, e.Err)
+		return fmt.Sprintf("%s (%d) | %s - %v", e.Code, e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s (%d) | %s", e.Code, e.Code, e.Message)
+}
+
+// WithTable sets the table the error is about and returns the error.
+func (e *AppError) WithTable(table string) *AppError {
+	e.Table = table
+	return e
+}
+
+// WithColumn sets the column the error is about and returns the error.
+func (e *AppError) WithColumn(column string) *AppError {
+	e.Column = column
+	return e
+}
+
+// TableOf returns the table of the first AppError in err's chain that names one, or "".
+func TableOf(err error) string {
+	for err != nil {
+		if appErr, ok := err.(*AppError); ok && appErr.Table != "" {
+			return appErr.Table
+		}
+		err = errors.Unwrap(err)
 	}
-	return fmt.Sprintf("%d | %s", e.Code, e.Message)
+	return ""
+}
+
+// ColumnOf returns the column of the first AppError in err's chain that names one, or "".
+func ColumnOf(err error) string {
+	for err != nil {
+		if appErr, ok := err.(*AppError); ok && appErr.Column != "" {
+			return appErr.Column
+		}
+		err = errors.Unwrap(err)
+	}
+	return ""
+}
+
+// Unwrap returns the underlying error, so errors.Is and errors.As can inspect it.
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches another AppError, or a bare ErrCode, with the same code.
+// This allows checks such as errors.Is(err, apperrors.ErrDBConnection).
+func (e *AppError) Is(target error) bool {
+	switch t := target.(type) {
+	case ErrCode:
+		return e.Code == t
+	case *AppError:
+		return t != nil && e.Code == t.Code
+	}
+	return false
+}
+
+// HasCode reports whether any error in err's chain is an AppError with the given code.
+func HasCode(err error, code ErrCode) bool {
+	return errors.Is(err, code)
 }
 
 func New(code ErrCode, message string, err error) *AppError {
@@ -0,0 +1,81 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"testing"
+)
+
+func TestAppErrorUnwrap(t *testing.T) {
+	inner := fmt.Errorf("reading config: %w", fs.ErrNotExist)
+	err := New(ErrFileRead, "failed to read", inner)
+
+	if got := errors.Unwrap(err); got != inner {
+		t.Errorf("Unwrap() = %v, want %v", got, inner)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("errors.Is does not see the wrapped sentinel")
+	}
+	if New(ErrUnknown, "no cause", nil).Unwrap() != nil {
+		t.Error("Unwrap() of an error without a cause is not nil")
+	}
+}
+
+func TestAppErrorIs(t *testing.T) {
+	err := fmt.Errorf("connecting: %w", New(ErrDBConnection, "failed to connect", io.EOF))
+	tests := []struct {
+		name   string
+		target error
+		want   bool
+	}{
+		{"same code", ErrDBConnection, true},
+		{"other code", ErrDBQuery, false},
+		{"AppError with the same code", New(ErrDBConnection, "other message", nil), true},
+		{"AppError with another code", New(ErrDBQuery, "failed to connect", io.EOF), false},
+		{"nil AppError", (*AppError)(nil), false},
+		{"wrapped cause", io.EOF, true},
+		{"other sentinel", io.ErrUnexpectedEOF, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(err, tt.target); got != tt.want {
+				t.Errorf("errors.Is(err, %v) = %v, want %v", tt.target, got, tt.want)
+			}
+		})
+	}
+	if !HasCode(err, ErrDBConnection) || HasCode(err, ErrDBQuery) {
+		t.Error("HasCode does not match the code of the wrapped AppError only")
+	}
+	if HasCode(io.EOF, ErrDBConnection) {
+		t.Error("HasCode matches an error that is no AppError")
+	}
+}
+
+func TestAppErrorAs(t *testing.T) {
+	inner := New(ErrDBQuery, "query failed", io.EOF).WithTable("[dbo].[Orders]")
+	err := New(ErrDataDump, "failed to dump table", fmt.Errorf("reading rows: %w", inner))
+
+	var appErr *AppError
+	if !errors.As(err, &appErr) || appErr != err {
+		t.Fatalf("errors.As found %v, want the outer error", appErr)
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		t.Error("errors.As found a *fs.PathError that is not in the chain")
+	}
+	if got := TableOf(err); got != "[dbo].[Orders]" {
+		t.Errorf("TableOf() = %q, want the table of the inner error", got)
+	}
+}
+
+func TestErrCodeError(t *testing.T) {
+	var err error = ErrDBConnection
+	if err.Error() != "ErrDBConnection" {
+		t.Errorf("Error() = %q, want %q", err.Error(), "ErrDBConnection")
+	}
+	if got := ErrCode(999).Error(); got != "ErrCode(999)" {
+		t.Errorf("Error() of an unknown code = %q, want %q", got, "ErrCode(999)")
+	}
+}
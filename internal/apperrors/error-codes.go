@@ -1,5 +1,7 @@
 package apperrors
 
+import "fmt"
+
 type ErrCode int
 
 const (
@@ -26,4 +28,40 @@ const (
 	ErrConcurrency       // An error related to concurrent processing (e.g., goroutine synchronization issues).
 	ErrResourceExhausted // Insufficient system resources (e.g., memory, file handles) to complete an operation.
 	ErrMigrateProcess    // An error during the migration process (e.g., script conversion issues).
+	ErrInterrupted       // The operation was canceled by the user (e.g., Ctrl+C) before completing.
 )
+
+var errCodeNames = map[ErrCode]string{
+	ErrUnknown:             "ErrUnknown",
+	ErrInvalidInput:        "ErrInvalidInput",
+	ErrConfigNotFound:      "ErrConfigNotFound",
+	ErrUnsupportedOption:   "ErrUnsupportedOption",
+	ErrOperationTimeout:    "ErrOperationTimeout",
+	ErrDBConnection:        "ErrDBConnection",
+	ErrDBQuery:             "ErrDBQuery",
+	ErrSchemaDump:          "ErrSchemaDump",
+	ErrDataDump:            "ErrDataDump",
+	ErrConstraintDump:      "ErrConstraintDump",
+	ErrTransaction:         "ErrTransaction",
+	ErrUnsupportedDatabase: "ErrUnsupportedDatabase",
+	ErrFileWrite:           "ErrFileWrite",
+	ErrFileRead:            "ErrFileRead",
+	ErrCLIParsing:          "ErrCLIParsing",
+	ErrConcurrency:         "ErrConcurrency",
+	ErrResourceExhausted:   "ErrResourceExhausted",
+	ErrMigrateProcess:      "ErrMigrateProcess",
+	ErrInterrupted:         "ErrInterrupted",
+}
+
+// String returns the constant name of the code, e.g. "ErrDBConnection".
+func (c ErrCode) String() string {
+	if name, ok := errCodeNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("ErrCode(%d)", int(c))
+}
+
+// Error makes ErrCode usable as an errors.Is target.
+func (c ErrCode) Error() string {
+	return c.String()
+}
@@ -1,26 +1,311 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"regexp"
+	"io"
+	"runtime"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/algermosen/go-erdos/util"
 )
 
 // DatabaseDriver defines the interface for all database drivers.
 // This interface abstracts the operations needed for the migration process.
+// Every method honors the context for cancellation and deadlines. When the context is
+// canceled mid-dump, the dump methods return the statements produced so far together
+// with an ErrInterrupted error, so callers can still save a valid partial dump.
 type DatabaseDriver interface {
 	// Connect opens a connection to the database using the provided connection string.
-	Connect(connectionString string) (*sql.DB, error)
+	Connect(ctx context.Context, connectionString string) (*sql.DB, error)
+
+	// DumpSchema returns the SQL statements for creating the database schema,
+	// leaving out the tables matching skip.
+	DumpSchema(ctx context.Context, db *sql.DB, skip util.TablePatterns) (string, error)
 
-	// DumpSchema returns the SQL statements for creating the database schema.
-	DumpSchema(db *sql.DB) (string, error)
+	// DumpData returns the SQL statements for inserting the database data,
+	// leaving out the rows of the tables matching skip.
+	DumpData(ctx context.Context, db *sql.DB, skip util.TablePatterns) (string, error)
 
-	// DumpData returns the SQL statements for inserting the database data.
-	DumpData(db *sql.DB) (string, error)
+	// WriteData writes the statements DumpData returns to w, table by table as they are dumped,
+	// so the data of a large database is never held in memory as a whole.
+	WriteData(ctx context.Context, db *sql.DB, skip util.TablePatterns, w io.Writer) error
 
 	// DumpConstraints returns the SQL statements for recreating constraints such as primary keys, foreign keys, etc.
-	DumpConstraints(db *sql.DB) (string, error)
+	DumpConstraints(ctx context.Context, db *sql.DB) (string, error)
+
+	// EstimateRows returns the approximate row count of every table, without scanning them.
+	EstimateRows(ctx context.Context, db *sql.DB) (map[TableName]int64, error)
+
+	// CountRows returns the exact row count of the given tables, scanning them.
+	CountRows(ctx context.Context, db *sql.DB, tables []TableName) (map[TableName]int64, error)
+
+	// Stats returns what the dump methods have produced since the driver was created.
+	Stats() DumpStats
+
+	// Tables returns the user tables of the database, sorted by name.
+	Tables(ctx context.Context, db *sql.DB) ([]TableName, error)
+
+	// DatabaseName returns the name of the database the connection uses.
+	DatabaseName(ctx context.Context, db *sql.DB) (string, error)
+
+	// Dependencies returns every table mapped to the tables its foreign keys reference.
+	Dependencies(ctx context.Context, db *sql.DB) (DependencyTree, error)
+
+	// DumpTable returns the requested parts of a single table's dump, optionally with the tables it depends on.
+	DumpTable(ctx context.Context, db *sql.DB, name string, parts DumpParts, withDependencies bool) (string, error)
+}
+
+// The optional capabilities below are implemented only by the drivers whose database supports
+// them. Commands needing one type-assert the driver and fail with ErrUnsupportedDatabase when
+// it is missing.
+
+// Copier copies data between two databases of its type.
+type Copier interface {
+	// CopyData copies the rows of every table from source into target, leaving out the tables matching skip.
+	// Rows are inserted with bound parameters instead of generated SQL text.
+	CopyData(ctx context.Context, source, target *sql.DB, skip util.TablePatterns) error
+}
+
+// Differ compares schemas and generates the script reconciling them.
+type Differ interface {
+	// DiffSchema compares the schemas of source and target, including their constraints if asked to.
+	DiffSchema(ctx context.Context, source, target *sql.DB, constraints bool) (SchemaDiff, error)
+
+	// MigrationScript returns the statements bringing the target of a diff in line with its source,
+	// including the drops of what only the target has when allowDestructive is set.
+	MigrationScript(diff SchemaDiff, allowDestructive bool) (string, error)
+}
+
+// Backupper takes and restores native backups, written and read by the database server.
+type Backupper interface {
+	// Backup writes a native full backup of the database to path, a file on the database server.
+	// With copyOnly, the backup does not affect the sequence of regular backups.
+	Backup(ctx context.Context, db *sql.DB, path string, copyOnly bool) error
+
+	// Restore restores the native backup at path as the given database, overwriting an existing
+	// database of that name only with replace.
+	Restore(ctx context.Context, db *sql.DB, path, database string, replace bool) error
+}
+
+// Querier is the part of *sql.DB the metadata and dump queries need. Accepting it instead of
+// *sql.DB lets those queries run against a transaction, a single connection or a test double.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+var (
+	_ Querier = (*sql.DB)(nil)
+	_ Querier = (*sql.Tx)(nil)
+	_ Querier = (*sql.Conn)(nil)
+)
+
+// DumpParts selects which sections are written to a dump.
+type DumpParts struct {
+	Schema      bool // CREATE SCHEMA and CREATE TABLE statements.
+	Data        bool // INSERT statements.
+	Constraints bool // Primary keys, unique constraints, indexes and foreign keys.
+}
+
+// Config holds the settings a driver uses while talking to the database.
+type Config struct {
+	// Retry controls how transient errors (deadlocks, timeouts) are retried.
+	Retry RetryPolicy
+
+	// Concurrency caps how many tables are dumped at the same time. Zero means DefaultConcurrency.
+	Concurrency int
+
+	// NoIdentityInsert leaves identity columns out of data dumps, so the target generates new values.
+	NoIdentityInsert bool
+
+	// ConnectTimeout bounds how long Connect waits for the server to answer. Zero means no limit.
+	ConnectTimeout time.Duration
+
+	// Connection pool limits. MaxOpenConns defaults to Concurrency + 1 (one connection per
+	// worker plus one for metadata queries) and MaxIdleConns to MaxOpenConns, so a dump never
+	// opens more connections than it can use. A zero ConnMaxLifetime keeps connections forever.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// Where filters the rows dumped from a table. Tables without an entry are dumped in full.
+	// Predicates are inserted verbatim into the SELECT, so they must come from a trusted source
+	// (see ValidatePredicate).
+	Where map[TableName]string
+
+	// Limit caps the number of rows dumped per table. Zero means unlimited. Rows are capped
+	// independently per table, so a limited dump can contain rows whose foreign keys point
+	// at rows that were left out.
+	Limit int
+
+	// Sample replaces the rows of the data section with a referentially consistent subset:
+	// Sample random rows of every table no other table references, and every row their foreign
+	// keys lead to. Zero dumps every row. SampleMaxRows caps the rows the sample may grow to,
+	// so a dense schema cannot pull in most of the database; zero means DefaultSampleMaxRows.
+	// Where still applies: random rows are only picked among the matching ones, and the rows
+	// the sample references but Where rejects are left out.
+	Sample        int
+	SampleMaxRows int
+
+	// Mask replaces the values of the listed columns in data dumps (see ParseMaskRules).
+	// Masked values are always written as string literals, so mask character columns only.
+	Mask MaskRules
+
+	// DropExisting makes schema dumps start by dropping the dumped tables, and every foreign key
+	// referencing them, so the dump can be loaded again over an existing database.
+	DropExisting bool
+
+	// IfNotExists guards every CREATE TABLE with an existence check, so schema dumps can be replayed.
+	IfNotExists bool
+
+	// FKNoCheck creates foreign keys WITH NOCHECK, so the existing rows are not validated, which
+	// is faster and tolerates legacy rows violating them. FKRecheck then validates them again
+	// with WITH CHECK CHECK CONSTRAINT once every key is created.
+	FKNoCheck bool
+	FKRecheck bool
+
+	// Descriptions adds the MS_Description extended properties of the tables and columns to
+	// schema dumps, after the CREATE TABLE statements.
+	Descriptions bool
+
+	// InsertBatchSize is the number of rows per generated INSERT statement, at most 1000
+	// (the SQL Server limit for a VALUES list). Zero means 50.
+	InsertBatchSize int
+
+	// TargetDialect is the dialect the schema and constraints are written in; nil means T-SQL.
+	// Data is always written in T-SQL.
+	TargetDialect Dialect
+
+	// RowCounts keeps the "rows affected" messages when the data dump is loaded; by default the
+	// data section starts with SET NOCOUNT ON. XactAbort adds SET XACT_ABORT ON, so a failing
+	// statement aborts its batch instead of letting the following ones run.
+	RowCounts bool
+	XactAbort bool
+
+	// DataTransaction wraps the data section in a single transaction, with XACT_ABORT ON, so a
+	// failed load rolls back entirely. The transaction must not span batches, so the tables are
+	// not separated by GO and the whole data section is one batch.
+	DataTransaction bool
+
+	// DisableForeignKeys brackets the data section with ALTER TABLE ... NOCHECK CONSTRAINT ALL
+	// for every dumped table and WITH CHECK CHECK CONSTRAINT ALL once the rows are in, so the
+	// data loads in any order into a database whose constraints already exist.
+	DisableForeignKeys bool
+
+	// NaturalDataOrder writes the data section in table name order instead of dependency order.
+	// Such a dump only loads while the foreign keys are disabled or not created yet, but it does
+	// not fail on foreign keys that form a cycle.
+	NaturalDataOrder bool
+
+	// OmitPreamble lists the statements of the data preamble, e.g. "SET NOCOUNT ON;", that the
+	// output already holds, such as when appending to an earlier dump; they are not written again.
+	OmitPreamble []string
+
+	// Resume holds the data sections of the tables fully dumped by an earlier run, which DumpData
+	// reuses instead of dumping them again. OnTableDumped is called, possibly concurrently, with
+	// the data section of every table DumpData finishes, so a caller can record it for resuming.
+	Resume        map[TableName]TableDump
+	OnTableDumped func(table TableName, dump TableDump)
+
+	// Progress receives the progress of dumps, copies, counts and backups; nil discards it.
+	Progress ProgressReporter
+
+	// BulkCopy makes CopyData load rows through the server's bulk load protocol where the table
+	// allows it, instead of INSERT statements.
+	BulkCopy bool
+
+	// IncludeSchemas limits the dump to the tables of these schemas; empty means every schema.
+	// ExcludeSchemas leaves every table of these schemas out, and wins over IncludeSchemas.
+	// A dumped table referencing a table that is left out is an error.
+	IncludeSchemas []string
+	ExcludeSchemas []string
+
+	// IncludeTables limits the dump to the tables matching these patterns; empty means every table.
+	// SkipTables leaves the matching tables out, and wins over IncludeTables. Both apply within
+	// the schemas kept by the schema filters.
+	IncludeTables util.TablePatterns
+	SkipTables    util.TablePatterns
+}
+
+// DefaultConcurrency returns how many tables are dumped at the same time when Config.Concurrency
+// is zero: GOMAXPROCS, capped at 8 so a dump does not swamp the server on large machines.
+func DefaultConcurrency() int {
+	return min(8, runtime.GOMAXPROCS(0))
+}
+
+// applyPoolLimits configures the connection pool of db from cfg, filling in the defaults.
+func (cfg Config) applyPoolLimits(db *sql.DB, concurrency int) {
+	maxOpen := cfg.MaxOpenConns
+	if maxOpen <= 0 {
+		maxOpen = concurrency + 1
+	}
+	maxIdle := cfg.MaxIdleConns
+	if maxIdle <= 0 {
+		maxIdle = maxOpen
+	}
+	db.SetMaxOpenConns(maxOpen)
+	db.SetMaxIdleConns(maxIdle)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+}
+
+// includesTable reports whether the table is part of the dump, according to the schema and table filters.
+func (cfg Config) includesTable(table TableName) bool {
+	schema, name := table.GetParts()
+	matches := func(name string) bool {
+		return strings.EqualFold(name, schema)
+	}
+	if len(cfg.IncludeSchemas) > 0 && !slices.ContainsFunc(cfg.IncludeSchemas, matches) {
+		return false
+	}
+	if slices.ContainsFunc(cfg.ExcludeSchemas, matches) {
+		return false
+	}
+	if len(cfg.IncludeTables) > 0 && !cfg.IncludeTables.Match(schema, name) {
+		return false
+	}
+	return !cfg.SkipTables.Match(schema, name)
+}
+
+// filterTables removes the tables left out by the schema and table filters from deps. It fails if a
+// remaining table references a removed one, since the dump could not be loaded on its own.
+func (cfg Config) filterTables(deps DependencyTree) error {
+	for _, table := range deps.tables() {
+		if !cfg.includesTable(table) {
+			delete(deps, table)
+			continue
+		}
+		for _, parent := range deps[table] {
+			if !cfg.includesTable(parent) {
+				msg := fmt.Sprintf("table %s references table %s, which is excluded from the dump", table, parent)
+				return apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+			}
+		}
+	}
+	return nil
+}
+
+// removeTables removes the tables matching skip from deps, together with the references to them.
+func (deps DependencyTree) removeTables(skip util.TablePatterns) {
+	skipped := func(table TableName) bool {
+		return skip.Match(table.GetParts())
+	}
+	for table, parents := range deps {
+		if skipped(table) {
+			delete(deps, table)
+			continue
+		}
+		deps[table] = slices.DeleteFunc(parents, skipped)
+	}
+}
+
+// TableDump is the data section of one table.
+type TableDump struct {
+	Data string
+	Rows int64
 }
 
 type DependencyTree map[TableName][]TableName
@@ -35,18 +320,34 @@ func NewTableName(schema, table string) TableName {
 	return TableName(FormatObjectName(schema, table))
 }
 
+// ParseTableName reads a user-supplied table name such as "Orders", "dbo.Orders" or "[dbo].[Orders]".
+// Names without a schema default to dbo.
+func ParseTableName(name string) TableName {
+	name = strings.TrimSpace(name)
+	if parts := splitObjectName(name); len(parts) > 0 {
+		if len(parts) == 1 {
+			return NewTableName("", parts[0])
+		}
+		return NewTableName(parts[len(parts)-2], parts[len(parts)-1])
+	}
+	if schema, table, found := strings.Cut(name, "."); found {
+		return NewTableName(schema, table)
+	}
+	return NewTableName("", name)
+}
+
 func (t TableName) String() string {
 	return string(t)
 }
 
+// GetParts returns the unescaped schema and table of a name like "[schema].[table]".
+// Three-part names ("[db].[schema].[table]") yield their last two parts.
 func (t TableName) GetParts() (string, string) {
-	// Define a regex that matches strings like "[schema].[table]"
-	re := regexp.MustCompile(`^\[([^]]+)\]\.\[([^]]+)\]$`) // ([^]]+) matches any character except ']'
-	matches := re.FindStringSubmatch(t.String())
-	if len(matches) == 3 {
-		return matches[1], matches[2]
+	parts := splitObjectName(t.String())
+	if len(parts) < 2 {
+		return "", ""
 	}
-	return "", ""
+	return parts[len(parts)-2], parts[len(parts)-1]
 }
 
 func (t TableName) IsEmpty() bool {
@@ -54,10 +355,53 @@ func (t TableName) IsEmpty() bool {
 	return strings.TrimSpace(table) == ""
 }
 
+// FormatObjectName quotes each part with square brackets and joins them with dots.
+// A closing bracket inside a part is doubled, as T-SQL requires.
 func FormatObjectName(parts ...string) string {
 	var formatted []string
 	for _, part := range parts {
-		formatted = append(formatted, fmt.Sprintf("[%s]", part))
+		formatted = append(formatted, fmt.Sprintf("[%s]", strings.ReplaceAll(part, "]", "]]")))
 	}
 	return strings.Join(formatted, ".")
 }
+
+// splitObjectName parses a dot-separated list of bracket-quoted parts, turning "]]" back into "]".
+// Dots inside brackets belong to the part. It returns nil if the name is not fully bracketed.
+func splitObjectName(name string) []string {
+	var parts []string
+	for i := 0; i < len(name); {
+		if name[i] != '[' {
+			return nil
+		}
+		i++
+
+		var part strings.Builder
+		closed := false
+		for i < len(name) {
+			if name[i] == ']' {
+				if i+1 < len(name) && name[i+1] == ']' {
+					part.WriteByte(']')
+					i += 2
+					continue
+				}
+				closed = true
+				i++
+				break
+			}
+			part.WriteByte(name[i])
+			i++
+		}
+		if !closed {
+			return nil
+		}
+		parts = append(parts, part.String())
+
+		if i < len(name) {
+			if name[i] != '.' || i+1 == len(name) {
+				return nil
+			}
+			i++
+		}
+	}
+	return parts
+}
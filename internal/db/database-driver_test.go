@@ -0,0 +1,114 @@
+package db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/algermosen/go-erdos/util"
+)
+
+func TestFormatObjectNameRoundTrip(t *testing.T) {
+	tests := []struct {
+		schema, table string
+		want          string
+	}{
+		{"dbo", "Orders", "[dbo].[Orders]"},
+		{"dbo", "Weird]Name", "[dbo].[Weird]]Name]"},
+		{"dbo", "[Bracketed]", "[dbo].[[Bracketed]]]"},
+		{"dbo", "My.Table", "[dbo].[My.Table]"},
+		{"sales.eu", "a].[b", "[sales.eu].[a]].[b]"},
+		{"dbo", "]]", "[dbo].[]]]]]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			name := FormatObjectName(tt.schema, tt.table)
+			if name != tt.want {
+				t.Fatalf("FormatObjectName(%q, %q) = %q, want %q", tt.schema, tt.table, name, tt.want)
+			}
+			schema, table := TableName(name).GetParts()
+			if schema != tt.schema || table != tt.table {
+				t.Errorf("GetParts() = (%q, %q), want (%q, %q)", schema, table, tt.schema, tt.table)
+			}
+		})
+	}
+}
+
+func TestTableNameGetParts(t *testing.T) {
+	tests := []struct {
+		name          TableName
+		schema, table string
+		empty         bool
+	}{
+		{"[dbo].[Orders]", "dbo", "Orders", false},
+		{"[dbo].[My.Table]", "dbo", "My.Table", false},
+		{"[we]]ird].[t]", "we]ird", "t", false},
+		{"[Shop].[sales].[Orders]", "sales", "Orders", false},
+		{"[Shop].[we]]ird].[My.Table]", "we]ird", "My.Table", false},
+		{"[Orders]", "", "", true},
+		{"dbo.Orders", "", "", true},
+		{"[dbo].[Orders", "", "", true},
+		{"[dbo].[]", "dbo", "", true},
+		{"", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.name), func(t *testing.T) {
+			schema, table := tt.name.GetParts()
+			if schema != tt.schema || table != tt.table {
+				t.Errorf("GetParts() = (%q, %q), want (%q, %q)", schema, table, tt.schema, tt.table)
+			}
+			if empty := tt.name.IsEmpty(); empty != tt.empty {
+				t.Errorf("IsEmpty() = %v, want %v", empty, tt.empty)
+			}
+		})
+	}
+}
+
+func TestConfigIncludesTable(t *testing.T) {
+	patterns := func(p ...string) util.TablePatterns {
+		parsed, err := util.ParseTablePatterns(p)
+		if err != nil {
+			t.Fatalf("ParseTablePatterns: %v", err)
+		}
+		return parsed
+	}
+	tests := []struct {
+		name string
+		cfg  Config
+		want map[string]bool // By "schema.table".
+	}{
+		{
+			name: "no filters",
+			want: map[string]bool{"dbo.Orders": true, "sales.Stores": true, "staging.Imports": true},
+		},
+		{
+			name: "include schemas only",
+			cfg:  Config{IncludeSchemas: []string{"dbo", "SALES"}},
+			want: map[string]bool{"dbo.Orders": true, "sales.Stores": true, "staging.Imports": false},
+		},
+		{
+			name: "exclude schemas only",
+			cfg:  Config{ExcludeSchemas: []string{"staging"}},
+			want: map[string]bool{"dbo.Orders": true, "sales.Stores": true, "staging.Imports": false},
+		},
+		{
+			name: "exclude wins inside the include set",
+			cfg:  Config{IncludeSchemas: []string{"dbo", "sales"}, ExcludeSchemas: []string{"Sales"}},
+			want: map[string]bool{"dbo.Orders": true, "sales.Stores": false, "staging.Imports": false},
+		},
+		{
+			name: "tables filtered within the kept schemas",
+			cfg:  Config{IncludeSchemas: []string{"dbo", "staging"}, IncludeTables: patterns("Orders", "Imports"), SkipTables: patterns("staging.*")},
+			want: map[string]bool{"dbo.Orders": true, "sales.Stores": false, "staging.Imports": false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for qualified, want := range tt.want {
+				schema, table, _ := strings.Cut(qualified, ".")
+				if got := tt.cfg.includesTable(NewTableName(schema, table)); got != want {
+					t.Errorf("includesTable(%s) = %v, want %v", qualified, got, want)
+				}
+			}
+		})
+	}
+}
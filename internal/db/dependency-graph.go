@@ -0,0 +1,60 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// tables returns the tables of the tree, sorted by name.
+func (d DependencyTree) tables() []TableName {
+	tables := make([]TableName, 0, len(d))
+	for table := range d {
+		tables = append(tables, table)
+	}
+	slices.Sort(tables)
+	return tables
+}
+
+// WriteDOT writes the tree as a Graphviz digraph, with edges pointing from child to parent.
+func (d DependencyTree) WriteDOT(w io.Writer) error {
+	var builder strings.Builder
+	builder.WriteString("digraph dependencies {\n")
+	builder.WriteString("    rankdir=LR;\n")
+	builder.WriteString("    node [shape=box];\n")
+	for _, table := range d.tables() {
+		builder.WriteString(fmt.Sprintf("    %s;\n", dotID(table)))
+	}
+	for _, table := range d.tables() {
+		parents := slices.Clone(d[table])
+		slices.Sort(parents)
+		for _, parent := range slices.Compact(parents) {
+			builder.WriteString(fmt.Sprintf("    %s -> %s;\n", dotID(table), dotID(parent)))
+		}
+	}
+	builder.WriteString("}\n")
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// WriteJSON writes the tree as a JSON object mapping every table to the tables it references.
+func (d DependencyTree) WriteJSON(w io.Writer) error {
+	graph := make(map[TableName][]TableName, len(d))
+	for table, parents := range d {
+		parents = slices.Clone(parents)
+		slices.Sort(parents)
+		graph[table] = append(make([]TableName, 0, len(parents)), slices.Compact(parents)...)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(graph)
+}
+
+// dotID quotes a table name as a DOT identifier.
+func dotID(table TableName) string {
+	return fmt.Sprintf("%q", table.String())
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+// ColumnType is the type of a column as read from the source database.
+type ColumnType struct {
+	Name      string // Type name, e.g. "nvarchar".
+	MaxLength int    // Length in bytes, -1 for (max) types.
+	Precision int
+	Scale     int
+}
+
+// TypeMapper turns source column types into the types of the dialect a schema is written in.
+// A Dialect embeds one, so supporting a new target mostly means writing its TypeMapper.
+type TypeMapper interface {
+	// ColumnType returns the type of a column for a CREATE TABLE statement, e.g. "varchar(50)".
+	ColumnType(col ColumnType) string
+	// IdentityClause returns what follows the type of an identity column.
+	IdentityClause() string
+}
+
+// Dialect is the SQL flavor the schema and constraints of a dump are written in.
+type Dialect interface {
+	TypeMapper
+	// QuoteName quotes each part of an object name and joins them with dots.
+	QuoteName(parts ...string) string
+	// CreateSchema returns the statement creating a schema if it is missing,
+	// or an empty string for the schemas every database already has.
+	CreateSchema(name string) string
+	// BatchSeparator ends a batch of statements.
+	BatchSeparator() string
+	// Collate returns the clause giving a character column a SQL Server collation, starting
+	// with a space, or an empty string if the dialect has no equivalent.
+	Collate(collation string) string
+}
+
+var (
+	_ TypeMapper = MSSQLTypeMapper{}
+	_ TypeMapper = PostgresTypeMapper{}
+)
+
+var dialects = map[string]Dialect{
+	"mssql":    mssqlDialect{},
+	"postgres": postgresDialect{},
+}
+
+// SupportedDialects returns the names of the dialects a dump can be written in, sorted.
+func SupportedDialects() []string {
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ParseDialect returns the dialect with the given name. An empty name means mssql.
+func ParseDialect(name string) (Dialect, error) {
+	if strings.TrimSpace(name) == "" {
+		return mssqlDialect{}, nil
+	}
+	dialect, exists := dialects[strings.ToLower(strings.TrimSpace(name))]
+	if !exists {
+		msg := fmt.Sprintf("unsupported target dialect '%s' (supported: %s)", name, strings.Join(SupportedDialects(), ", "))
+		return nil, apperrors.New(apperrors.ErrUnsupportedOption, msg, nil)
+	}
+	return dialect, nil
+}
+
+// MSSQLTypeMapper keeps the SQL Server types as read.
+type MSSQLTypeMapper struct{}
+
+func (MSSQLTypeMapper) ColumnType(col ColumnType) string {
+	dt := strings.ToLower(col.Name)
+	switch dt {
+	case "char", "varchar", "nchar", "nvarchar", "binary", "varbinary":
+		// SQL Server reports max_length = -1 for (max) columns.
+		if col.MaxLength == -1 {
+			return col.Name + "(max)"
+		}
+		return fmt.Sprintf("%s(%d)", col.Name, charLength(col))
+	case "decimal", "numeric":
+		return fmt.Sprintf("%s(%d,%d)", col.Name, col.Precision, col.Scale)
+	default:
+		return col.Name
+	}
+}
+
+func (MSSQLTypeMapper) IdentityClause() string {
+	return "IDENTITY(1,1)"
+}
+
+// mssqlDialect writes the schema as read, in T-SQL.
+type mssqlDialect struct {
+	MSSQLTypeMapper
+}
+
+func (mssqlDialect) QuoteName(parts ...string) string {
+	return FormatObjectName(parts...)
+}
+
+func (mssqlDialect) CreateSchema(name string) string {
+	if slices.Contains([]string{"dbo", "sys", "INFORMATION_SCHEMA"}, name) {
+		return ""
+	}
+	return GetCreateSchemaQuery(name)
+}
+
+func (mssqlDialect) BatchSeparator() string {
+	return BatchSeparator
+}
+
+func (mssqlDialect) Collate(collation string) string {
+	return " COLLATE " + collation
+}
+
+// PostgresTypeMapper maps SQL Server types to their PostgreSQL equivalents. Types without one
+// (e.g. geography, sql_variant) keep their SQL Server name, so loading fails visibly.
+type PostgresTypeMapper struct{}
+
+// postgresTypes maps the SQL Server types whose PostgreSQL equivalent takes no length.
+var postgresTypes = map[string]string{
+	"bit":              "boolean",
+	"tinyint":          "smallint",
+	"smallint":         "smallint",
+	"int":              "integer",
+	"bigint":           "bigint",
+	"float":            "double precision",
+	"real":             "real",
+	"money":            "numeric(19,4)",
+	"smallmoney":       "numeric(10,4)",
+	"text":             "text",
+	"ntext":            "text",
+	"image":            "bytea",
+	"rowversion":       "bytea",
+	"timestamp":        "bytea", // SQL Server's timestamp is a rowversion, not a date.
+	"date":             "date",
+	"time":             "time",
+	"datetime":         "timestamp",
+	"datetime2":        "timestamp",
+	"smalldatetime":    "timestamp",
+	"datetimeoffset":   "timestamptz",
+	"uniqueidentifier": "uuid",
+	"xml":              "xml",
+}
+
+func (PostgresTypeMapper) ColumnType(col ColumnType) string {
+	dt := strings.ToLower(col.Name)
+	switch dt {
+	case "char", "nchar", "varchar", "nvarchar":
+		if col.MaxLength == -1 {
+			return "text"
+		}
+		if dt == "char" || dt == "nchar" {
+			return fmt.Sprintf("char(%d)", charLength(col))
+		}
+		return fmt.Sprintf("varchar(%d)", charLength(col))
+	case "binary", "varbinary":
+		return "bytea"
+	case "decimal", "numeric":
+		return fmt.Sprintf("numeric(%d,%d)", col.Precision, col.Scale)
+	}
+	if mapped, ok := postgresTypes[dt]; ok {
+		return mapped
+	}
+	return dt
+}
+
+func (PostgresTypeMapper) IdentityClause() string {
+	// BY DEFAULT rather than ALWAYS, so rows migrated with their ids can still be inserted.
+	return "GENERATED BY DEFAULT AS IDENTITY"
+}
+
+// postgresDialect transpiles the schema to PostgreSQL.
+type postgresDialect struct {
+	PostgresTypeMapper
+}
+
+func (postgresDialect) QuoteName(parts ...string) string {
+	var quoted []string
+	for _, part := range parts {
+		quoted = append(quoted, `"`+strings.ReplaceAll(part, `"`, `""`)+`"`)
+	}
+	return strings.Join(quoted, ".")
+}
+
+func (d postgresDialect) CreateSchema(name string) string {
+	// Unlike SQL Server, PostgreSQL has no dbo schema, so every schema is created.
+	return fmt.Sprintf("\nCREATE SCHEMA IF NOT EXISTS %s;\n", d.QuoteName(name))
+}
+
+func (postgresDialect) BatchSeparator() string {
+	return "\n\n"
+}
+
+func (postgresDialect) Collate(collation string) string {
+	// SQL Server collation names mean nothing to PostgreSQL, which uses its default instead.
+	return ""
+}
+
+// charLength returns the length of a character or binary column in characters.
+func charLength(col ColumnType) int {
+	dt := strings.ToLower(col.Name)
+	if dt == "nchar" || dt == "nvarchar" {
+		// For 'nchar' and 'nvarchar', max_length is in bytes (2 bytes per character).
+		return col.MaxLength / 2
+	}
+	return col.MaxLength
+}
@@ -0,0 +1,58 @@
+package db
+
+import "testing"
+
+type columnTypeTest struct {
+	col  ColumnType
+	want string
+}
+
+// testColumnTypes checks the type the mapper gives every column.
+func testColumnTypes(t *testing.T, mapper TypeMapper, tests []columnTypeTest) {
+	t.Helper()
+	for _, tt := range tests {
+		if got := mapper.ColumnType(tt.col); got != tt.want {
+			t.Errorf("ColumnType(%+v) = %q, want %q", tt.col, got, tt.want)
+		}
+	}
+}
+
+func TestMSSQLTypeMapperCharacterLengths(t *testing.T) {
+	testColumnTypes(t, MSSQLTypeMapper{}, []columnTypeTest{
+		// max_length is in bytes, two per character for the n-types.
+		{ColumnType{Name: "nvarchar", MaxLength: 100}, "nvarchar(50)"},
+		{ColumnType{Name: "nchar", MaxLength: 20}, "nchar(10)"},
+		{ColumnType{Name: "varchar", MaxLength: 100}, "varchar(100)"},
+		{ColumnType{Name: "nvarchar", MaxLength: -1}, "nvarchar(max)"},
+		{ColumnType{Name: "varchar", MaxLength: -1}, "varchar(max)"},
+	})
+}
+
+func TestMSSQLTypeMapperBinaryLengths(t *testing.T) {
+	testColumnTypes(t, MSSQLTypeMapper{}, []columnTypeTest{
+		// Binary lengths are in bytes already.
+		{ColumnType{Name: "varbinary", MaxLength: 256}, "varbinary(256)"},
+		{ColumnType{Name: "varbinary", MaxLength: -1}, "varbinary(max)"},
+		{ColumnType{Name: "binary", MaxLength: 16}, "binary(16)"},
+	})
+}
+
+func TestMSSQLTypeMapper(t *testing.T) {
+	testColumnTypes(t, MSSQLTypeMapper{}, []columnTypeTest{
+		{ColumnType{Name: "char", MaxLength: 10}, "char(10)"},
+		{ColumnType{Name: "nchar", MaxLength: 2}, "nchar(1)"},
+		{ColumnType{Name: "decimal", Precision: 18, Scale: 2}, "decimal(18,2)"},
+		{ColumnType{Name: "numeric", Precision: 38, Scale: 0}, "numeric(38,0)"},
+		{ColumnType{Name: "DECIMAL", Precision: 5, Scale: 5}, "DECIMAL(5,5)"},
+		{ColumnType{Name: "nvarchar", MaxLength: -1}, "nvarchar(max)"},
+		{ColumnType{Name: "varchar", MaxLength: -1}, "varchar(max)"},
+		{ColumnType{Name: "varbinary", MaxLength: -1}, "varbinary(max)"},
+		// Types without a length or precision are kept as they are.
+		{ColumnType{Name: "int", MaxLength: 4, Precision: 10}, "int"},
+		{ColumnType{Name: "datetime2", MaxLength: 8, Precision: 27, Scale: 7}, "datetime2"},
+		{ColumnType{Name: "uniqueidentifier", MaxLength: 16}, "uniqueidentifier"},
+	})
+	if got := (MSSQLTypeMapper{}).IdentityClause(); got != "IDENTITY(1,1)" {
+		t.Errorf("IdentityClause() = %q, want IDENTITY(1,1)", got)
+	}
+}
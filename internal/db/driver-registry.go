@@ -0,0 +1,97 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+// DriverConstructor builds a driver using the given configuration.
+type DriverConstructor func(cfg Config) DatabaseDriver
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]DriverConstructor)
+)
+
+// Register makes a driver available under the given database type name.
+// Registering the same name twice panics, as it is a programming error.
+func Register(name string, constructor DriverConstructor) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	name = strings.ToLower(name)
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("db: driver %q registered twice", name))
+	}
+	registry[name] = constructor
+}
+
+// GetDriver returns the driver registered for dbType, configured with cfg.
+func GetDriver(dbType string, cfg Config) (DatabaseDriver, error) {
+	registryMu.RLock()
+	constructor, exists := registry[strings.ToLower(dbType)]
+	registryMu.RUnlock()
+
+	if !exists {
+		msg := fmt.Sprintf("unsupported database type '%s' (supported: %s)", dbType, strings.Join(SupportedDrivers(), ", "))
+		return nil, apperrors.New(apperrors.ErrUnsupportedDatabase, msg, nil)
+	}
+	return constructor(cfg), nil
+}
+
+// SupportedDrivers returns the names of all registered drivers, sorted.
+func SupportedDrivers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// InferDriver guesses the database type of a connection string from its form, returning an empty
+// string when it cannot tell. URLs are recognized by their scheme (sqlserver://, postgres://,
+// file:), key=value strings by the keys only one of the databases uses.
+func InferDriver(connStr string) string {
+	lower := strings.ToLower(strings.TrimSpace(connStr))
+	if scheme, _, found := strings.Cut(lower, "://"); found {
+		switch scheme {
+		case "sqlserver", "mssql":
+			return "mssql"
+		case "postgres", "postgresql":
+			return "postgres"
+		case "sqlite", "sqlite3", "file":
+			return "sqlite"
+		}
+		return ""
+	}
+	if strings.HasPrefix(lower, "file:") || strings.HasSuffix(lower, ".db") ||
+		strings.HasSuffix(lower, ".sqlite") || strings.HasSuffix(lower, ".sqlite3") {
+		return "sqlite"
+	}
+
+	// ADO strings are "key=value;" pairs, libpq ones space-separated "key=value" pairs.
+	if pairs, err := splitConnPairs(lower); err == nil {
+		for key := range pairs {
+			switch key {
+			case "server", "data source", "initial catalog", "user id", "trustservercertificate":
+				return "mssql"
+			}
+		}
+	}
+	for _, pair := range strings.Fields(lower) {
+		key, _, _ := strings.Cut(pair, "=")
+		switch key {
+		case "host", "dbname", "sslmode":
+			return "postgres"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// DumpStats summarizes what the dump methods of a driver have produced.
+type DumpStats struct {
+	Tables        int      // Tables written to the dump, in any section.
+	Rows          int64    // Rows written as INSERT statements.
+	SkippedTables []string // Tables whose data was skipped on request.
+	FailedTables  []string // Tables whose data could not be dumped.
+
+	// Bytes and Duration are the size and time taken of a dump written by WriteDump. The
+	// driver's Stats leaves them at zero.
+	Bytes    int64
+	Duration time.Duration
+}
+
+// statsRecorder accumulates DumpStats from concurrent table dumps.
+type statsRecorder struct {
+	mu      sync.Mutex
+	tables  map[TableName]struct{}
+	rows    int64
+	skipped []string
+	failed  []string
+}
+
+func (r *statsRecorder) addTable(table TableName, rows int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tables == nil {
+		r.tables = make(map[TableName]struct{})
+	}
+	r.tables[table] = struct{}{}
+	r.rows += rows
+}
+
+func (r *statsRecorder) skip(table TableName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.skipped = append(r.skipped, table.String())
+}
+
+func (r *statsRecorder) fail(table TableName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed = append(r.failed, table.String())
+}
+
+// snapshot returns a copy of the recorded stats, with table lists sorted.
+func (r *statsRecorder) snapshot() DumpStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := DumpStats{
+		Tables:        len(r.tables),
+		Rows:          r.rows,
+		SkippedTables: slices.Clone(r.skipped),
+		FailedTables:  slices.Clone(r.failed),
+	}
+	slices.Sort(stats.SkippedTables)
+	slices.Sort(stats.FailedTables)
+	return stats
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/algermosen/go-erdos/util"
+)
+
+// DumpRequest selects what WriteDump writes.
+type DumpRequest struct {
+	// Parts are the sections written, in the order schema, data, constraints.
+	Parts DumpParts
+
+	// Table, when set, limits the dump to this table (e.g. "dbo.Orders"), together with the
+	// tables it references when WithDependencies is set.
+	Table            string
+	WithDependencies bool
+
+	// SkipSchema matches the tables left out of the schema section, and SkipData those whose
+	// rows are left out of the data section.
+	SkipSchema util.TablePatterns
+	SkipData   util.TablePatterns
+
+	// Dialect is the dialect the driver writes the schema and constraints in, which decides
+	// how those sections are separated; nil means T-SQL.
+	Dialect Dialect
+}
+
+// WriteDump writes the requested sections of a dump to w, each as soon as it is produced, so
+// the data section streams table by table. When the context is canceled, what was produced
+// so far is written and an ErrInterrupted error is returned; on any other error, w may hold
+// part of the dump. The stats are returned in every case: they are the driver's Stats, with
+// the bytes written to w and the time the dump took.
+func WriteDump(ctx context.Context, driver DatabaseDriver, db *sql.DB, w io.Writer, req DumpRequest) (DumpStats, error) {
+	start := time.Now()
+	counter := &byteCounter{w: w}
+	err := writeDump(ctx, driver, db, counter, req)
+	stats := driver.Stats()
+	stats.Bytes = counter.n
+	stats.Duration = time.Since(start)
+	return stats, err
+}
+
+// writeDump writes the sections of a dump for WriteDump.
+func writeDump(ctx context.Context, driver DatabaseDriver, db *sql.DB, w io.Writer, req DumpRequest) error {
+	dialect := req.Dialect
+	if dialect == nil {
+		dialect = mssqlDialect{}
+	}
+	write := func(s string) error {
+		if _, err := io.WriteString(w, s); err != nil {
+			return apperrors.New(apperrors.ErrFileWrite, "failed to write dump", err)
+		}
+		return nil
+	}
+	// finish writes the output of a section and tells whether the next one may run.
+	finish := func(output string, err error) (bool, error) {
+		interrupted := apperrors.HasCode(err, apperrors.ErrInterrupted) || errors.Is(err, context.Canceled)
+		if err != nil && !interrupted {
+			return false, err
+		}
+		if writeErr := write(output); writeErr != nil {
+			return false, writeErr
+		}
+		return err == nil, err
+	}
+
+	if req.Table != "" {
+		_, err := finish(driver.DumpTable(ctx, db, req.Table, req.Parts, req.WithDependencies))
+		return err
+	}
+
+	if req.Parts.Schema {
+		schema, err := driver.DumpSchema(ctx, db, req.SkipSchema)
+		if next, err := finish(schema+dialect.BatchSeparator(), err); !next {
+			return err
+		}
+	}
+	if req.Parts.Data {
+		err := driver.WriteData(ctx, db, req.SkipData, w)
+		if next, err := finish(BatchSeparator, err); !next {
+			return err
+		}
+	}
+	if req.Parts.Constraints {
+		constraints, err := driver.DumpConstraints(ctx, db)
+		if _, err := finish(constraints+dialect.BatchSeparator(), err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// byteCounter counts the bytes written through it.
+type byteCounter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/algermosen/go-erdos/util"
+)
+
+// Masker replaces a column value before it is written to a data dump.
+// It receives the value as text and returns the text to insert instead;
+// NULL values are never passed to a Masker.
+type Masker interface {
+	Mask(value string) string
+}
+
+// MaskerFunc adapts an ordinary function to the Masker interface.
+type MaskerFunc func(value string) string
+
+// Mask calls f(value).
+func (f MaskerFunc) Mask(value string) string {
+	return f(value)
+}
+
+// MaskRules maps a table to the maskers applied to its columns, by column name.
+type MaskRules map[TableName]map[string]Masker
+
+var (
+	maskersMu sync.RWMutex
+	maskers   = map[string]Masker{
+		"redact":    MaskerFunc(redactValue),
+		"hash":      MaskerFunc(hashValue),
+		"email":     MaskerFunc(fakeEmail),
+		"fake-name": MaskerFunc(fakeName),
+	}
+)
+
+// RegisterMasker makes a masking strategy available under the given name.
+// Registering the same name twice panics, as it is a programming error.
+func RegisterMasker(name string, masker Masker) {
+	maskersMu.Lock()
+	defer maskersMu.Unlock()
+
+	name = strings.ToLower(name)
+	if _, exists := maskers[name]; exists {
+		panic(fmt.Sprintf("db: masker %q registered twice", name))
+	}
+	maskers[name] = masker
+}
+
+// SupportedMaskers returns the names of all registered masking strategies, sorted.
+func SupportedMaskers() []string {
+	maskersMu.RLock()
+	defer maskersMu.RUnlock()
+
+	names := make([]string, 0, len(maskers))
+	for name := range maskers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ParseMaskRules reads a comma-separated list of "table.column:strategy" rules,
+// e.g. "Customers.Email:email,sales.Customers.SSN:redact". Tables without a schema default to dbo.
+func ParseMaskRules(spec string) (MaskRules, error) {
+	rules := make(MaskRules)
+	for _, rule := range util.SplitAndTrim(spec, ",") {
+		column, strategy, found := strings.Cut(rule, ":")
+		if !found {
+			msg := fmt.Sprintf("invalid mask rule '%s' (expected table.column:strategy)", rule)
+			return nil, apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+		}
+
+		parts := splitObjectName(strings.TrimSpace(column))
+		if parts == nil {
+			parts = util.SplitAndTrim(column, ".")
+		}
+		if len(parts) < 2 || len(parts) > 3 {
+			msg := fmt.Sprintf("invalid mask column '%s' (expected table.column or schema.table.column)", column)
+			return nil, apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+		}
+		table := NewTableName("", parts[len(parts)-2])
+		if len(parts) == 3 {
+			table = NewTableName(parts[0], parts[1])
+		}
+
+		maskersMu.RLock()
+		masker, exists := maskers[strings.ToLower(strings.TrimSpace(strategy))]
+		maskersMu.RUnlock()
+		if !exists {
+			msg := fmt.Sprintf("unsupported mask strategy '%s' (supported: %s)", strategy, strings.Join(SupportedMaskers(), ", "))
+			return nil, apperrors.New(apperrors.ErrUnsupportedOption, msg, nil).WithTable(table.String()).WithColumn(parts[len(parts)-1])
+		}
+
+		if rules[table] == nil {
+			rules[table] = make(map[string]Masker)
+		}
+		rules[table][strings.ToLower(parts[len(parts)-1])] = masker
+	}
+	return rules, nil
+}
+
+// forColumn returns the masker for a column of the given table, or nil if it is not masked.
+func (r MaskRules) forColumn(table TableName, column string) Masker {
+	return r[table][strings.ToLower(column)]
+}
+
+// redactValue replaces every character with an X, keeping the original length.
+func redactValue(value string) string {
+	return strings.Repeat("X", len([]rune(value)))
+}
+
+// hashValue replaces the value with its SHA-256 digest, so equal values stay equal.
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// fakeEmail replaces the value with an address derived from its hash, keeping unique values unique.
+func fakeEmail(value string) string {
+	return fmt.Sprintf("user-%s@example.com", hashValue(value)[:12])
+}
+
+var fakeNames = []string{
+	"Alex Morgan", "Jamie Lee", "Taylor Reed", "Jordan Blake", "Casey Quinn",
+	"Riley Parker", "Morgan Hayes", "Avery Brooks", "Drew Ellis", "Sam Carter",
+}
+
+// fakeName replaces the value with a name picked from its hash, so the same input always maps to the same name.
+func fakeName(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return fakeNames[int(sum[0])%len(fakeNames)]
+}
+
+// maskText applies masker to a scanned, non-NULL value.
+func maskText(masker Masker, val interface{}) string {
+	var text string
+	switch v := val.(type) {
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case time.Time:
+		text = v.Format("2006-01-02 15:04:05")
+	default:
+		text = fmt.Sprint(v)
+	}
+	return masker.Mask(text)
+}
+
+// maskValue applies masker to a scanned, non-NULL value and returns it as a quoted string literal.
+func maskValue(masker Masker, val interface{}) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(maskText(masker, val), "'", "''"))
+}
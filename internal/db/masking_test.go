@@ -0,0 +1,116 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+// maskedDump dumps a single masked column of dbo.T holding value, and returns the written value.
+func maskedDump(t *testing.T, strategy string, value any) string {
+	t.Helper()
+	rules, err := ParseMaskRules("T.V:" + strategy)
+	if err != nil {
+		t.Fatalf("ParseMaskRules: %v", err)
+	}
+	dump := dumpRows(t, NewMSSQLDriver(Config{Mask: rules}), []columnDef{mockColumn(1, "V", "nvarchar")}, nil,
+		"SELECT [V] FROM [dbo].[T]", sqlmock.NewRows([]string{"V"}).AddRow(value))
+	_, values, found := strings.Cut(dump, "VALUES \n(")
+	if !found {
+		t.Fatalf("dump = %q, want an INSERT", dump)
+	}
+	return strings.TrimSuffix(strings.Split(values, "\n")[0], ");")
+}
+
+func TestMaskRedact(t *testing.T) {
+	if got := maskedDump(t, "redact", "123-45-6789"); got != "'XXXXXXXXXXX'" {
+		t.Errorf("redacted value = %s, want one X per character", got)
+	}
+	if got := maskedDump(t, "redact", "Zoë"); got != "'XXX'" {
+		t.Errorf("redacted value = %s, want one X per character, not per byte", got)
+	}
+}
+
+func TestMaskHash(t *testing.T) {
+	sum := sha256.Sum256([]byte("secret"))
+	want := "'" + hex.EncodeToString(sum[:]) + "'"
+	if got := maskedDump(t, "hash", []byte("secret")); got != want {
+		t.Errorf("hashed value = %s, want %s", got, want)
+	}
+	if maskedDump(t, "hash", "other") == want {
+		t.Error("different values hash the same")
+	}
+}
+
+func TestMaskEmail(t *testing.T) {
+	first := maskedDump(t, "email", "ada@lovelace.org")
+	if !regexp.MustCompile(`^'user-[0-9a-f]{12}@example\.com'$`).MatchString(first) {
+		t.Errorf("masked email = %s, want a user-<hash>@example.com address", first)
+	}
+	if again := maskedDump(t, "email", "ada@lovelace.org"); again != first {
+		t.Errorf("masked email = %s, then %s, want equal inputs to mask the same", first, again)
+	}
+	if other := maskedDump(t, "email", "grace@hopper.org"); other == first {
+		t.Error("different addresses mask the same")
+	}
+}
+
+func TestMaskFakeName(t *testing.T) {
+	first := maskedDump(t, "fake-name", "O'Brien")
+	if !slices.Contains(fakeNames, strings.Trim(first, "'")) {
+		t.Errorf("fake name = %s, want one of %v", first, fakeNames)
+	}
+	if again := maskedDump(t, "fake-name", "O'Brien"); again != first {
+		t.Errorf("fake name = %s, then %s, want equal inputs to mask the same", first, again)
+	}
+}
+
+func TestMaskNullAndUnmasked(t *testing.T) {
+	if got := maskedDump(t, "redact", nil); got != "NULL" {
+		t.Errorf("masked NULL = %s, want NULL", got)
+	}
+	rules, err := ParseMaskRules("Other.V:redact")
+	if err != nil {
+		t.Fatalf("ParseMaskRules: %v", err)
+	}
+	dump := dumpRows(t, NewMSSQLDriver(Config{Mask: rules}), []columnDef{mockColumn(1, "V", "nvarchar")}, nil,
+		"SELECT [V] FROM [dbo].[T]", sqlmock.NewRows([]string{"V"}).AddRow("kept"))
+	if !strings.Contains(dump, "('kept')") {
+		t.Errorf("dump = %q, want the column of another table's rule kept", dump)
+	}
+}
+
+func TestParseMaskRules(t *testing.T) {
+	rules, err := ParseMaskRules("Customers.Email:email, sales.Customers.SSN:REDACT, [dbo].[Odd.Table].[Col]:hash")
+	if err != nil {
+		t.Fatalf("ParseMaskRules: %v", err)
+	}
+	for _, rule := range []struct {
+		table  TableName
+		column string
+	}{
+		{NewTableName("dbo", "Customers"), "EMAIL"},
+		{NewTableName("sales", "Customers"), "ssn"},
+		{NewTableName("dbo", "Odd.Table"), "Col"},
+	} {
+		if rules.forColumn(rule.table, rule.column) == nil {
+			t.Errorf("no masker for %s.%s", rule.table, rule.column)
+		}
+	}
+
+	for _, spec := range []string{"Customers.Email", "Email:redact", "Customers.Email:scramble"} {
+		if _, err := ParseMaskRules(spec); err == nil {
+			t.Errorf("ParseMaskRules(%q) succeeded, want an error", spec)
+		}
+	}
+	_, err = ParseMaskRules("Customers.Email:scramble")
+	if !apperrors.HasCode(err, apperrors.ErrUnsupportedOption) || apperrors.TableOf(err) != "[dbo].[Customers]" || apperrors.ColumnOf(err) != "Email" {
+		t.Errorf("error = %v, want an ErrUnsupportedOption naming the table and column", err)
+	}
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/golang-sql/sqlexp"
+)
+
+// percentProcessed matches the progress messages BACKUP and RESTORE print with the STATS option.
+var percentProcessed = regexp.MustCompile(`^(\d+) percent processed`)
+
+// Backup writes a full, compressed backup of the connected database to path. The path is on the
+// database server, not on the machine running erdos. The file is formatted, so any backup sets
+// it already holds are overwritten. With copyOnly, the differential base and the log chain are
+// left alone.
+func (m *MSSQLDriver) Backup(ctx context.Context, db *sql.DB, path string, copyOnly bool) error {
+	name, err := m.DatabaseName(ctx, db)
+	if err != nil {
+		return err
+	}
+	options := "FORMAT, COMPRESSION, STATS = 5"
+	if copyOnly {
+		options += ", COPY_ONLY"
+	}
+	// These are native statements, so the target dialect of dumps does not apply.
+	stmt := fmt.Sprintf("BACKUP DATABASE %s TO DISK = %s WITH %s;", mssqlDialect{}.QuoteName(name), unicodeLiteral(path), options)
+	if err := m.execWithProgress(ctx, db, stmt, "Backing up"); err != nil {
+		return m.backupError(ctx, "backup", err)
+	}
+	return nil
+}
+
+// Restore restores the backup at path, a file on the database server, as the given database.
+// An existing database of that name is only overwritten with replace.
+func (m *MSSQLDriver) Restore(ctx context.Context, db *sql.DB, path, database string, replace bool) error {
+	options := "STATS = 5"
+	if replace {
+		options += ", REPLACE"
+	}
+	// The session leaves the database first, as a restore needs it to be unused.
+	stmt := fmt.Sprintf("USE [master];\nRESTORE DATABASE %s FROM DISK = %s WITH %s;", mssqlDialect{}.QuoteName(database), unicodeLiteral(path), options)
+	if err := m.execWithProgress(ctx, db, stmt, "Restoring"); err != nil {
+		return m.backupError(ctx, "restore", err)
+	}
+	return nil
+}
+
+// backupError wraps the error of a backup or restore, telling an interruption apart.
+func (m *MSSQLDriver) backupError(ctx context.Context, operation string, err error) error {
+	if ctx.Err() != nil {
+		return apperrors.New(apperrors.ErrInterrupted, operation+" interrupted", err)
+	}
+	return apperrors.New(apperrors.ErrDBQuery, operation+" failed", err)
+}
+
+// execWithProgress runs a statement that reports its progress through "N percent processed"
+// messages, as a task of 100 steps with the given name.
+func (m *MSSQLDriver) execWithProgress(ctx context.Context, db *sql.DB, stmt, task string) error {
+	messages := &sqlexp.ReturnMessage{}
+	rows, err := db.QueryContext(ctx, stmt, messages)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	progress := m.progress()
+	progress.Start(task, 100)
+	defer progress.Done()
+	reported := 0
+	for active := true; active; {
+		switch msg := messages.Message(ctx).(type) {
+		case sqlexp.MsgNotice:
+			if match := percentProcessed.FindStringSubmatch(msg.Message.String()); match != nil {
+				percent, _ := strconv.Atoi(match[1])
+				progress.Increment(percent - reported)
+				reported = percent
+			}
+		case sqlexp.MsgNext:
+			for rows.Next() {
+			}
+		case sqlexp.MsgNextResultSet:
+			active = rows.NextResultSet()
+		case sqlexp.MsgError:
+			return msg.Error
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return rows.Err()
+}
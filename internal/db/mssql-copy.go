@@ -0,0 +1,253 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+	"github.com/algermosen/go-erdos/util"
+	mssql "github.com/denisenkom/go-mssqldb"
+)
+
+// mssqlMaxParameters is the number of parameters SQL Server accepts in a single request.
+const mssqlMaxParameters = 2100
+
+// rawBinaryTypes are the types whose scanned []byte value is sent back as binary.
+// Other types (decimal, money, ...) are scanned as []byte text and are sent back as strings.
+var rawBinaryTypes = []string{"binary", "varbinary", "image", "uniqueidentifier", "geometry", "hierarchyid"}
+
+// CopyData copies the rows of every table from source into target, in dependency order,
+// leaving out the tables matching skip. Values are sent as query parameters rather than
+// SQL text, so no escaping is involved.
+func (m *MSSQLDriver) CopyData(ctx context.Context, source, target *sql.DB, skip util.TablePatterns) error {
+	tables, err := m.getSortedTables(ctx, source)
+	if err != nil {
+		return err
+	}
+	mappings, err := m.getTableMappings(ctx, source)
+	if err != nil {
+		return fmt.Errorf("MSSQL error fetching mappings: %w", err)
+	}
+	primaryKeys, err := m.getPrimaryKeyColumns(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	progress := m.progress()
+	progress.Start("Copying data", len(tables))
+	defer progress.Done()
+	for _, table := range tables {
+		if err := ctx.Err(); err != nil {
+			return apperrors.New(apperrors.ErrInterrupted, "data copy interrupted", err)
+		}
+
+		if skip.Match(table.GetParts()) {
+			m.stats.skip(table)
+			progress.Increment(1)
+			continue
+		}
+
+		rows, err := m.copyTableData(ctx, source, target, table, mappings[table], primaryKeys[table])
+		if err != nil {
+			m.stats.fail(table)
+			if ctx.Err() != nil {
+				return apperrors.New(apperrors.ErrInterrupted, "data copy interrupted", err)
+			}
+			if apperrors.TableOf(err) == "" {
+				err = apperrors.New(apperrors.ErrDataDump, "failed to copy table", err).WithTable(table.String())
+			}
+			return err
+		}
+		m.stats.addTable(table, rows)
+		progress.Increment(1)
+	}
+	return nil
+}
+
+// copyTableData copies the rows of one table into the target, through bulk load when enabled
+// and possible, or else with parameterized INSERTs, and returns how many were copied.
+func (m *MSSQLDriver) copyTableData(ctx context.Context, source, target *sql.DB, table TableName, colInfo []columnDef, primaryKey []string) (int64, error) {
+	columns := m.insertableColumns(colInfo)
+	if len(columns) == 0 {
+		return 0, nil
+	}
+	var colNames []string
+	masks := make([]Masker, len(columns))
+	for i, col := range columns {
+		colNames = append(colNames, FormatObjectName(col.columnName))
+		masks[i] = m.cfg.Mask.forColumn(table, col.columnName)
+	}
+	colList := strings.Join(colNames, ", ")
+
+	var rows *sql.Rows
+	err := withRetry(ctx, m.cfg.Retry, func() error {
+		var err error
+		rows, err = source.QueryContext(ctx, m.selectRowsQuery(table.String(), colList, primaryKey, ""))
+		return err
+	})
+	if err != nil {
+		return 0, apperrors.New(apperrors.ErrDataDump, "failed to query data", err).WithTable(table.String())
+	}
+	defer rows.Close()
+
+	// SET IDENTITY_INSERT only lasts for the session, so every statement goes through the same connection.
+	conn, err := target.Conn(ctx)
+	if err != nil {
+		return 0, apperrors.New(apperrors.ErrDBConnection, "failed to get a target connection", err)
+	}
+	defer conn.Close()
+
+	if slices.ContainsFunc(columns, func(col columnDef) bool { return col.isIdentity }) {
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET IDENTITY_INSERT %s ON;", table)); err != nil {
+			return 0, apperrors.New(apperrors.ErrDBQuery, "failed to enable IDENTITY_INSERT", err).WithTable(table.String())
+		}
+		defer conn.ExecContext(context.WithoutCancel(ctx), fmt.Sprintf("SET IDENTITY_INSERT %s OFF;", table))
+	}
+
+	if m.cfg.BulkCopy && bulkCopyable(columns) {
+		return m.bulkCopyRows(ctx, conn, rows, table, columns, masks)
+	}
+	return m.insertRows(ctx, conn, rows, table, colList, columns, masks)
+}
+
+// insertRows writes the rows with multi-row parameterized INSERTs and returns how many were copied.
+func (m *MSSQLDriver) insertRows(ctx context.Context, conn *sql.Conn, rows *sql.Rows, table TableName, colList string, columns []columnDef, masks []Masker) (int64, error) {
+	// Multi-row inserts are limited both by the configured batch size and by the parameter limit.
+	batch := min(m.insertBatchSize(), (mssqlMaxParameters-1)/len(columns))
+	var args []interface{}
+	var groups []string
+	var copied int64
+	flush := func() error {
+		if len(groups) == 0 {
+			return nil
+		}
+		stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s;", table, colList, strings.Join(groups, ", "))
+		if _, err := conn.ExecContext(ctx, stmt, args...); err != nil {
+			return apperrors.New(apperrors.ErrDBQuery, "failed to insert rows", err).WithTable(table.String())
+		}
+		copied += int64(len(groups))
+		args, groups = args[:0], groups[:0]
+		return nil
+	}
+
+	for rows.Next() {
+		values, err := scanRow(rows, len(columns))
+		if err != nil {
+			return copied, apperrors.New(apperrors.ErrDataDump, "failed to scan row", err).WithTable(table.String())
+		}
+
+		placeholders := make([]string, len(columns))
+		for i, val := range values {
+			placeholders[i] = fmt.Sprintf("@p%d", len(args)+1)
+			args = append(args, copyValue(columns[i], masks[i], val))
+		}
+		groups = append(groups, fmt.Sprintf("(%s)", strings.Join(placeholders, ", ")))
+
+		if len(groups) >= batch {
+			if err := flush(); err != nil {
+				return copied, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return copied, apperrors.New(apperrors.ErrDataDump, "error iterating rows", err).WithTable(table.String())
+	}
+	return copied, flush()
+}
+
+// bulkCopyRows streams the rows through the TDS bulk load protocol and returns how many were copied.
+func (m *MSSQLDriver) bulkCopyRows(ctx context.Context, conn *sql.Conn, rows *sql.Rows, table TableName, columns []columnDef, masks []Masker) (int64, error) {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.columnName
+	}
+	stmt, err := conn.PrepareContext(ctx, mssql.CopyIn(table.String(), mssql.BulkOptions{KeepNulls: true}, names...))
+	if err != nil {
+		return 0, apperrors.New(apperrors.ErrDBQuery, "failed to start bulk copy", err).WithTable(table.String())
+	}
+	defer stmt.Close()
+
+	for rows.Next() {
+		values, err := scanRow(rows, len(columns))
+		if err != nil {
+			return 0, apperrors.New(apperrors.ErrDataDump, "failed to scan row", err).WithTable(table.String())
+		}
+		for i, val := range values {
+			if val != nil {
+				values[i] = copyValue(columns[i], masks[i], val)
+			}
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return 0, apperrors.New(apperrors.ErrDBQuery, "failed to bulk copy a row", err).WithTable(table.String())
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, apperrors.New(apperrors.ErrDataDump, "error iterating rows", err).WithTable(table.String())
+	}
+
+	// Executing without arguments sends the buffered rows and ends the bulk load.
+	result, err := stmt.ExecContext(ctx)
+	if err != nil {
+		return 0, apperrors.New(apperrors.ErrDBQuery, "failed to bulk copy rows", err).WithTable(table.String())
+	}
+	return result.RowsAffected()
+}
+
+// bulkCopyTypes are the column types the driver's bulk load supports.
+var bulkCopyTypes = []string{
+	"bigint", "int", "smallint", "tinyint", "bit", "float", "real", "decimal", "numeric",
+	"char", "varchar", "text", "nchar", "nvarchar", "ntext",
+	"date", "time", "datetime", "datetime2", "smalldatetime", "datetimeoffset",
+	"binary", "varbinary", "uniqueidentifier",
+}
+
+// bulkCopyable reports whether a table's rows can go through bulk load. Bulk load cannot keep
+// identity values, and does not support every type (e.g. money, xml, geography), so such tables
+// fall back to parameterized INSERTs.
+func bulkCopyable(columns []columnDef) bool {
+	for _, col := range columns {
+		if col.isIdentity || !slices.Contains(bulkCopyTypes, strings.ToLower(col.dataType)) {
+			return false
+		}
+	}
+	return true
+}
+
+// scanRow scans the current row into a slice of n values.
+func scanRow(rows *sql.Rows, n int) ([]interface{}, error) {
+	values := make([]interface{}, n)
+	valuePtrs := make([]interface{}, n)
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// copyValue turns a scanned value into the parameter sent to the target.
+func copyValue(col columnDef, masker Masker, val interface{}) interface{} {
+	binary := slices.Contains(rawBinaryTypes, strings.ToLower(col.dataType))
+	if val == nil {
+		// An untyped NULL is sent as nvarchar, which does not convert implicitly to binary types.
+		if binary {
+			return []byte(nil)
+		}
+		return nil
+	}
+	// Geography values are not copied yet, in line with the text dump.
+	if strings.EqualFold(col.dataType, "geography") {
+		return nil
+	}
+	if masker != nil {
+		return maskText(masker, val)
+	}
+	if b, ok := val.([]byte); ok && !binary {
+		return string(b)
+	}
+	return val
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+// dumpDescriptions emits the MS_Description extended properties of the given tables and their
+// columns as sp_addextendedproperty calls, which must run after the tables are created.
+func (m *MSSQLDriver) dumpDescriptions(ctx context.Context, db Querier, tables []TableName) (string, error) {
+	var rows *sql.Rows
+	err := withRetry(ctx, m.cfg.Retry, func() error {
+		var err error
+		rows, err = db.QueryContext(ctx, mssqlQueryDescriptions)
+		return err
+	})
+	if err != nil {
+		return "", apperrors.New(apperrors.ErrDBQuery, "error fetching descriptions", err)
+	}
+	defer rows.Close()
+
+	dumped := make(map[TableName]bool, len(tables))
+	for _, table := range tables {
+		dumped[table] = true
+	}
+
+	var builder strings.Builder
+	for rows.Next() {
+		var schema, table, description string
+		var column sql.NullString // NULL for the description of the table itself.
+		if err := rows.Scan(&schema, &table, &column, &description); err != nil {
+			return "", apperrors.New(apperrors.ErrDBQuery, "error scanning description row", err)
+		}
+		if !dumped[NewTableName(schema, table)] {
+			continue
+		}
+		if builder.Len() == 0 {
+			builder.WriteString("-- Descriptions\n")
+		}
+		stmt := fmt.Sprintf("EXEC sp_addextendedproperty @name = N'MS_Description', @value = %s, @level0type = N'SCHEMA', @level0name = %s, @level1type = N'TABLE', @level1name = %s",
+			unicodeLiteral(description), unicodeLiteral(schema), unicodeLiteral(table))
+		if column.Valid {
+			stmt += fmt.Sprintf(", @level2type = N'COLUMN', @level2name = %s", unicodeLiteral(column.String))
+		}
+		builder.WriteString(stmt + ";\n")
+	}
+	if err := rows.Err(); err != nil {
+		return "", apperrors.New(apperrors.ErrDBQuery, "error iterating description rows", err)
+	}
+	if builder.Len() > 0 {
+		builder.WriteString("\n")
+	}
+	return builder.String(), nil
+}
+
+// unicodeLiteral returns s as an N'...' string literal.
+func unicodeLiteral(s string) string {
+	return "N'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
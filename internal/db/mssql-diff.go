@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+// DiffSchema compares the tables and columns of source and target, and with constraints also
+// their primary keys, unique constraints and foreign keys. Both databases are only read.
+func (m *MSSQLDriver) DiffSchema(ctx context.Context, source, target *sql.DB, constraints bool) (SchemaDiff, error) {
+	sourceMappings, err := m.getTableMappings(ctx, source)
+	if err != nil {
+		return SchemaDiff{}, fmt.Errorf("MSSQL error fetching source mappings: %w", err)
+	}
+	targetMappings, err := m.getTableMappings(ctx, target)
+	if err != nil {
+		return SchemaDiff{}, fmt.Errorf("MSSQL error fetching target mappings: %w", err)
+	}
+	diff := diffTableMappings(sourceMappings, targetMappings)
+
+	if constraints {
+		sourceConstraints, err := m.describeConstraints(ctx, source)
+		if err != nil {
+			return SchemaDiff{}, err
+		}
+		targetConstraints, err := m.describeConstraints(ctx, target)
+		if err != nil {
+			return SchemaDiff{}, err
+		}
+		diff.SourceOnlyConstraints, diff.TargetOnlyConstraints = diffConstraints(sourceConstraints, targetConstraints)
+	}
+	return diff, nil
+}
+
+// describeConstraints returns every primary key, unique constraint and foreign key of the database
+// as "table definition".
+func (m *MSSQLDriver) describeConstraints(ctx context.Context, db Querier) ([]string, error) {
+	constraints, err := m.getConstraints(ctx, db, nil)
+	if err != nil {
+		return nil, err
+	}
+	var described []string
+	for _, kc := range append(constraints.primaryKeys, constraints.uniqueConstraints...) {
+		described = append(described, fmt.Sprintf("%s %s", FormatObjectName(kc.schema, kc.table), kc.definition(FormatObjectName, true)))
+	}
+	for _, fk := range constraints.foreignKeys {
+		described = append(described, fmt.Sprintf("%s %s", FormatObjectName(fk.childSchema, fk.childTable), fk.definition(FormatObjectName)))
+	}
+	return described, nil
+}
+
+// MigrationScript returns the statements bringing the target of diff in line with its source:
+// new tables first, then new columns, then column changes. Dropping the columns and tables
+// only the target has loses data, so those statements are only written with allowDestructive;
+// otherwise they are listed as comments. Constraints are not migrated.
+func (m *MSSQLDriver) MigrationScript(diff SchemaDiff, allowDestructive bool) (string, error) {
+	var builder strings.Builder
+	builder.WriteString("-- Schema migration\n\n")
+
+	if len(diff.SourceOnlyTables) > 0 {
+		newTables := make(TableMapping, len(diff.SourceOnlyTables))
+		for _, table := range diff.SourceOnlyTables {
+			newTables[table] = diff.source[table]
+		}
+		for _, table := range newTables.tables() {
+			stmt, err := m.assembleCreateStatements(TableMapping{table: newTables[table]})
+			if err != nil {
+				return "", apperrors.New(apperrors.ErrSchemaDump, "failed to assemble the CREATE statements", err).WithTable(table.String())
+			}
+			builder.WriteString(stmt)
+		}
+	}
+
+	for _, table := range diff.Tables {
+		for _, name := range table.SourceOnlyColumns {
+			col, _ := findColumn(diff.source[table.Table], name)
+			// Adding a NOT NULL column fails on a table with rows, which is better than inventing a default.
+			builder.WriteString(fmt.Sprintf("ALTER TABLE %s ADD %s;\n", table.Table, m.buildColumnDefinition(col)))
+		}
+	}
+
+	for _, table := range diff.Tables {
+		for _, change := range table.ChangedColumns {
+			col, _ := findColumn(diff.source[table.Table], change.Column)
+			other, _ := findColumn(diff.target[table.Table], change.Column)
+			if col.isIdentity != other.isIdentity {
+				// IDENTITY cannot be added or removed with ALTER COLUMN.
+				builder.WriteString(fmt.Sprintf("-- Not migrated: %s.%s changes IDENTITY (%s -> %s); the table must be rebuilt.\n",
+					table.Table, FormatObjectName(change.Column), change.Target, change.Source))
+				continue
+			}
+			def := col
+			def.isIdentity = false
+			builder.WriteString(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s;\n", table.Table, m.buildColumnDefinition(def)))
+		}
+	}
+
+	var drops strings.Builder
+	for _, table := range diff.Tables {
+		for _, name := range table.TargetOnlyColumns {
+			drops.WriteString(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;\n", table.Table, FormatObjectName(name)))
+		}
+	}
+	if len(diff.TargetOnlyTables) > 0 {
+		drops.WriteString(GetDropTablesQuery(diff.TargetOnlyTables))
+	}
+	if drops.Len() > 0 {
+		builder.WriteString("\n-- Destructive changes\n")
+		if allowDestructive {
+			builder.WriteString(drops.String())
+		} else {
+			builder.WriteString("-- Left out, as they lose data; rerun with --allow-destructive to include them:\n")
+			for _, line := range strings.Split(strings.TrimSpace(drops.String()), "\n") {
+				builder.WriteString("-- " + line + "\n")
+			}
+		}
+	}
+	return builder.String(), nil
+}
@@ -3,7 +3,10 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"strings"
 	"sync"
 	"time"
@@ -15,95 +18,543 @@ import (
 )
 
 // MSSQLDriver implements the DatabaseDriver interface for Microsoft SQL Server.
-type MSSQLDriver struct{}
+type MSSQLDriver struct {
+	cfg   Config
+	stats statsRecorder
+
+	// sample holds the row filters of a sampled data dump, set before its tables are dumped.
+	// A table's rows are read with one query per filter.
+	sample map[TableName][]string
+}
+
+var (
+	_ DatabaseDriver = (*MSSQLDriver)(nil)
+	_ Copier         = (*MSSQLDriver)(nil)
+	_ Differ         = (*MSSQLDriver)(nil)
+	_ Backupper      = (*MSSQLDriver)(nil)
+)
+
+func init() {
+	Register("mssql", func(cfg Config) DatabaseDriver {
+		return NewMSSQLDriver(cfg)
+	})
+}
 
 // NewMSSQLDriver creates a new instance of MSSQLDriver.
-func NewMSSQLDriver() *MSSQLDriver {
-	return &MSSQLDriver{}
+func NewMSSQLDriver(cfg Config) *MSSQLDriver {
+	return &MSSQLDriver{cfg: cfg}
+}
+
+// Stats returns what the dump methods have produced since the driver was created.
+func (m *MSSQLDriver) Stats() DumpStats {
+	return m.stats.snapshot()
+}
+
+// concurrency returns how many tables may be dumped at the same time.
+func (m *MSSQLDriver) concurrency() int {
+	if m.cfg.Concurrency > 0 {
+		return m.cfg.Concurrency
+	}
+	return DefaultConcurrency()
+}
+
+// dialect returns the dialect the schema and constraints are written in.
+func (m *MSSQLDriver) dialect() Dialect {
+	if m.cfg.TargetDialect == nil {
+		return mssqlDialect{}
+	}
+	return m.cfg.TargetDialect
+}
+
+// progress returns the reporter of Config.Progress, or one that discards the progress.
+func (m *MSSQLDriver) progress() ProgressReporter {
+	if m.cfg.Progress == nil {
+		return noProgress{}
+	}
+	return m.cfg.Progress
+}
+
+func (m *MSSQLDriver) insertBatchSize() int {
+	if m.cfg.InsertBatchSize > 0 {
+		return m.cfg.InsertBatchSize
+	}
+	return 50
 }
 
 // Connect establishes a connection to the MSSQL database.
-func (m *MSSQLDriver) Connect(connectionString string) (*sql.DB, error) {
+func (m *MSSQLDriver) Connect(ctx context.Context, connectionString string) (*sql.DB, error) {
 	db, err := sql.Open("sqlserver", connectionString)
 	if err != nil {
 		// Use our custom error type with ErrDBConnection error code.
 		return nil, apperrors.New(apperrors.ErrDBConnection, "failed to connect to MSSQL", err)
 	}
 
-	// Verify the connection with a ping.
-	if err := db.Ping(); err != nil {
-		return nil, apperrors.New(apperrors.ErrDBConnection, "MSSQL ping failed", err)
+	m.cfg.applyPoolLimits(db, m.concurrency())
+
+	// Verify the connection with a ping, bounded by the connect timeout.
+	pingCtx := ctx
+	if m.cfg.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		pingCtx, cancel = context.WithTimeout(ctx, m.cfg.ConnectTimeout)
+		defer cancel()
+	}
+	if err := db.PingContext(pingCtx); err != nil {
+		db.Close()
+		return nil, m.connectError(err)
 	}
 	return db, nil
 }
 
-// DumpSchema returns a placeholder string for the schema dump.
-// In a real implementation, this would query system views like INFORMATION_SCHEMA.TABLES, etc.
-func (m *MSSQLDriver) DumpSchema(db *sql.DB) (string, error) {
-	// Placeholder: Replace with actual schema extraction logic.
-	deps, err := m.analyzeDependencies(db)
-	if err != nil {
-		return "", fmt.Errorf("MSSQL error analyzing dependencies: %w", err)
+// mssqlLoginErrors lists the SQL Server error numbers raised for rejected credentials.
+var mssqlLoginErrors = []int32{18452, 18456}
+
+// connectError wraps a failed ping, telling unreachable hosts apart from rejected credentials.
+func (m *MSSQLDriver) connectError(err error) error {
+	var numbered sqlErrorNumber
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		msg := fmt.Sprintf("could not reach MSSQL host within %s", m.cfg.ConnectTimeout)
+		return apperrors.New(apperrors.ErrDBConnection, msg, err)
+	case errors.As(err, &numbered) && slices.Contains(mssqlLoginErrors, numbered.SQLErrorNumber()):
+		return apperrors.New(apperrors.ErrDBConnection, "MSSQL authentication failed", err)
+	case errors.As(err, &netErr):
+		return apperrors.New(apperrors.ErrDBConnection, "could not reach MSSQL host", err)
+	default:
+		return apperrors.New(apperrors.ErrDBConnection, "MSSQL ping failed", err)
 	}
+}
 
-	rows, err := db.Query(tableListQuery)
+// DumpSchema returns the CREATE SCHEMA and CREATE TABLE statements of the dumped tables, in dependency order.
+// Tables left out by the Config filters (--skip, --include-tables and the schema filters) or
+// matching skip are dropped from the table list and dependency tree, and so get no CREATE TABLE;
+// a schema whose tables are all dropped gets no CREATE SCHEMA either. A kept table may reference
+// a skipped one, since its foreign keys are part of the constraints.
+func (m *MSSQLDriver) DumpSchema(ctx context.Context, db *sql.DB, skip util.TablePatterns) (string, error) {
+	deps, err := m.getDependencyTree(ctx, db)
 	if err != nil {
-		return "", apperrors.New(apperrors.ErrDBQuery, "failed to query table list", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var schema, table string
-		if err := rows.Scan(&schema, &table); err != nil {
-			return "", apperrors.New(apperrors.ErrDBQuery, "failed to scan table list", err)
-		}
-		fullTableName := NewTableName(schema, table)
-		if _, exists := deps[fullTableName]; !exists {
-			deps[fullTableName] = make([]TableName, 0)
-		}
-	}
-	if err := rows.Err(); err != nil {
-		return "", apperrors.New(apperrors.ErrDBQuery, "error iterating table list", err)
+		return "", err
 	}
+	deps.removeTables(skip)
 
 	sortedTables, err := sortTablesByDependencies(deps)
 	if err != nil {
 		return "", fmt.Errorf("MSSQL error sorting dependencies: %w", err)
 	}
+	return m.dumpSchema(ctx, db, sortedTables)
+}
 
-	mappings, err := m.getTableMappings(db)
+// dumpSchema emits the CREATE statements of the given tables, in the given order.
+func (m *MSSQLDriver) dumpSchema(ctx context.Context, db Querier, sortedTables []TableName) (string, error) {
+	mappings, err := m.getTableMappings(ctx, db)
 	if err != nil {
 		return "", fmt.Errorf("MSSQL error fetching mappings: %w", err)
 	}
 
 	var builder strings.Builder
-	var schemas = []string{"dbo", "sys", "INFORMATION_SCHEMA"}
+	if m.cfg.DropExisting && len(sortedTables) > 0 {
+		builder.WriteString("-- Drop existing tables\n")
+		builder.WriteString(GetDropTablesQuery(sortedTables) + BatchSeparator)
+	}
+	// Statements are assembled concurrently, like the data, and then written in dependency order.
+	statements := make([]string, len(sortedTables))
+	assembled := make([]bool, len(sortedTables))
+	errs := make([]error, len(sortedTables))
+	progress := m.progress()
+	progress.Start("Dumping schemas", len(sortedTables))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < m.concurrency(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				table := sortedTables[i]
+				statements[i], errs[i] = m.assembleCreateStatements(TableMapping{table: mappings[table]})
+				assembled[i] = true
+				progress.Increment(1)
+			}
+		}()
+	}
+feed:
+	for i := range sortedTables {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	progress.Done()
+
+	// CREATE SCHEMA goes right before the first table of each schema.
+	var schemas []string
 	for i, table := range sortedTables {
-		fmt.Printf("\033[1A\033[K[Dumping schemas (%d/%d)]\n", i+1, len(sortedTables))
+		if !assembled[i] {
+			return builder.String(), apperrors.New(apperrors.ErrInterrupted, "schema dump interrupted", ctx.Err())
+		}
+		if errs[i] != nil {
+			return "", apperrors.New(apperrors.ErrSchemaDump, "failed to assemble the CREATE statements", errs[i]).WithTable(table.String())
+		}
 		schema, _ := table.GetParts()
 		if !slices.Contains(schemas, schema) {
-			builder.WriteString(GetCreateSchemaQuery(schema))
+			builder.WriteString(m.dialect().CreateSchema(schema))
 			schemas = append(schemas, schema)
 		}
-		stm, err := m.assembleCreateStatements(TableMapping{table: mappings[table]})
+		builder.WriteString(statements[i])
+		m.stats.addTable(table, 0)
+	}
+
+	if m.cfg.Descriptions {
+		descriptions, err := m.dumpDescriptions(ctx, db, sortedTables)
 		if err != nil {
-			return "", fmt.Errorf("MSSQL error assembling statement of [%s]: %w", table, err)
+			return "", err
 		}
-		builder.WriteString(stm)
+		builder.WriteString(descriptions)
 	}
+	return builder.String(), nil
+}
 
-	fmt.Println()
+// DumpData returns the INSERT statements of every table, in dependency order.
+func (m *MSSQLDriver) DumpData(ctx context.Context, db *sql.DB, skip util.TablePatterns) (string, error) {
+	var builder strings.Builder
+	if err := m.WriteData(ctx, db, skip, &builder); err != nil {
+		if apperrors.HasCode(err, apperrors.ErrInterrupted) {
+			return builder.String(), err
+		}
+		return "", err
+	}
 	return builder.String(), nil
 }
 
-// DumpData returns a placeholder string for the data dump.
-// You would typically iterate over tables and generate INSERT statements for each row.
-func (m *MSSQLDriver) DumpData(db *sql.DB, skip []string) (string, error) {
-	// Query to get the list of tables with their schema.
-	// getting this list is also used in the schema dump. Consider refactoring to avoid duplication.
-	rows, err := db.Query(tableListQuery)
+// WriteData writes the INSERT statements of every table to w, in dependency order unless
+// NaturalDataOrder asks for name order.
+func (m *MSSQLDriver) WriteData(ctx context.Context, db *sql.DB, skip util.TablePatterns, w io.Writer) error {
+	tables, err := m.getDataTables(ctx, db)
+	if err != nil {
+		return err
+	}
+	return m.dumpData(ctx, db, tables, skip, w)
+}
+
+// getDataTables returns the tables of the data section, in the order they are written.
+func (m *MSSQLDriver) getDataTables(ctx context.Context, db Querier) ([]TableName, error) {
+	if !m.cfg.NaturalDataOrder {
+		// Tables are dumped in dependency order so parents load before their children.
+		return m.getSortedTables(ctx, db)
+	}
+	deps, err := m.getDependencyTree(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]TableName, 0, len(deps))
+	for table := range deps {
+		tables = append(tables, table)
+	}
+	slices.Sort(tables)
+	return tables, nil
+}
+
+// dumpData writes the INSERT statements of the given tables to w, in the given order. Each table
+// is written as soon as it and every table before it are dumped, so only the tables finished
+// ahead of a slower one are held in memory.
+func (m *MSSQLDriver) dumpData(ctx context.Context, db *sql.DB, tables []TableName, skip util.TablePatterns, w io.Writer) error {
+	mappings, err := m.getTableMappings(ctx, db)
+	if err != nil {
+		return fmt.Errorf("MSSQL error fetching mappings: %w", err)
+	}
+
+	primaryKeys, err := m.getPrimaryKeyColumns(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if m.cfg.Sample > 0 {
+		var sampled []TableName
+		for _, table := range tables {
+			if !skip.Match(table.GetParts()) {
+				sampled = append(sampled, table)
+			}
+		}
+		if m.sample, err = m.planSample(ctx, db, sampled, mappings, primaryKeys); err != nil {
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	// results holds the finished tables not written yet; skipped and failed ones have no statements.
+	results := make(map[TableName]string, len(tables))
+	failures := make(map[TableName]error)
+
+	// A failed write cancels the remaining tables, which could not be written either.
+	dumpCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var writeErr error
+	write := func(s string) {
+		if writeErr != nil {
+			return
+		}
+		if _, err := io.WriteString(w, s); err != nil {
+			writeErr = apperrors.New(apperrors.ErrFileWrite, "failed to write data dump", err)
+			cancel(writeErr)
+		}
+	}
+	// flush writes the finished tables that no unfinished one precedes. It is called with mu held.
+	next := 0
+	flush := func() {
+		for ; next < len(tables); next++ {
+			dump, finished := results[tables[next]]
+			if !finished {
+				return
+			}
+			write(dump)
+			delete(results, tables[next])
+		}
+	}
+
+	write(m.dataPreamble())
+	if m.cfg.DisableForeignKeys {
+		// In a batch of their own, ahead of the transaction of --data-transaction.
+		write(toggleConstraints(tables, false) + BatchSeparator)
+	}
+	if m.cfg.DataTransaction {
+		write("BEGIN TRANSACTION;\n\n")
+	}
+
+	// Every row dumped counts as a step, out of the estimated rows of the tables not skipped;
+	// a sample is capped at its maximum rows.
+	estimates, err := m.EstimateRows(ctx, db)
+	if err != nil {
+		return err
+	}
+	var totalRows int64
+	for _, table := range tables {
+		if !skip.Match(table.GetParts()) {
+			totalRows += estimates[table]
+		}
+	}
+	if m.cfg.Sample > 0 {
+		maxRows := m.cfg.SampleMaxRows
+		if maxRows <= 0 {
+			maxRows = DefaultSampleMaxRows
+		}
+		totalRows = min(totalRows, int64(maxRows))
+	}
+	progress := m.progress()
+	progress.Start("Dumping data", int(totalRows))
+
+	// Dump tables concurrently with a 1-minute timeout per table,
+	// capping the number of simultaneous dumps to the configured concurrency.
+	dumpOne := func(tbl TableName) {
+		// Create a new context for this cycle with a 1-minute timeout.
+		ctxCycle, cancelCycle := context.WithTimeout(dumpCtx, time.Minute)
+		defer cancelCycle()
+
+		if skip.Match(tbl.GetParts()) {
+			m.stats.skip(tbl)
+			mu.Lock()
+			results[tbl] = ""
+			flush()
+			mu.Unlock()
+			return
+		}
+		if resumed, ok := m.cfg.Resume[tbl]; ok {
+			mu.Lock()
+			results[tbl] = resumed.Data
+			m.stats.addTable(tbl, resumed.Rows)
+			flush()
+			mu.Unlock()
+			progress.Increment(int(resumed.Rows))
+			return
+		}
+
+		// The whole read is retried, so a deadlock while the rows are read starts the table over.
+		var tableRows int64
+		var dump string
+		err := withRetry(ctxCycle, m.cfg.Retry, func() error {
+			tableRows = 0
+			var err error
+			dump, err = m.dumpTableData(ctxCycle, db, tbl.String(), mappings[tbl], primaryKeys[tbl], func() {
+				tableRows++
+				progress.Increment(1)
+			})
+			return err
+		})
+		complete := false
+		mu.Lock()
+		switch {
+		case errors.Is(err, context.Canceled):
+			// Interrupted: keep the rows dumped so far, the interruption is reported once all workers stop.
+			results[tbl] = dump
+			m.stats.addTable(tbl, tableRows)
+			err = nil
+		case err != nil:
+			results[tbl] = ""
+			if apperrors.TableOf(err) == "" {
+				err = apperrors.New(apperrors.ErrDataDump, "failed to dump table", err).WithTable(tbl.String())
+			}
+			failures[tbl] = err
+			m.stats.fail(tbl)
+		default:
+			results[tbl] = dump
+			m.stats.addTable(tbl, tableRows)
+			complete = true
+		}
+		flush()
+		mu.Unlock()
+		if complete && m.cfg.OnTableDumped != nil {
+			m.cfg.OnTableDumped(tbl, TableDump{Data: dump, Rows: tableRows})
+		}
+	}
+
+	jobs := make(chan TableName)
+	for w := 0; w < m.concurrency(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tbl := range jobs {
+				dumpOne(tbl)
+			}
+		}()
+	}
+feed:
+	for _, table := range tables {
+		select {
+		case jobs <- table:
+		case <-dumpCtx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+
+	wg.Wait()
+	progress.Done()
+
+	if writeErr != nil {
+		return writeErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		// The tables cut short are written too, in order, so every row dumped is kept.
+		for _, table := range tables[next:] {
+			write(results[table])
+		}
+		if writeErr != nil {
+			return writeErr
+		}
+		// No COMMIT: loading an interrupted dump rolls its transaction back when the session ends.
+		return apperrors.New(apperrors.ErrInterrupted, "data dump interrupted", err)
+	}
+
+	if len(failures) > 0 {
+		var errs []error
+		for _, table := range tables {
+			if err, failed := failures[table]; failed {
+				errs = append(errs, err)
+			}
+		}
+		msg := fmt.Sprintf("failed to dump %d of %d tables", len(failures), len(tables))
+		return apperrors.New(apperrors.ErrDataDump, msg, errors.Join(errs...))
+	}
+	if m.cfg.DataTransaction {
+		write("COMMIT TRANSACTION;\n")
+	}
+	if m.cfg.DisableForeignKeys {
+		// Not written on interruption or failure either: the rows loaded could not pass the check.
+		write("\n" + toggleConstraints(tables, true))
+	}
+	return writeErr
+}
+
+// toggleConstraints disables the foreign key and check constraints of the given tables, or
+// enables them again, validating the existing rows so the optimizer keeps trusting them.
+func toggleConstraints(tables []TableName, enable bool) string {
+	action := "NOCHECK"
+	if enable {
+		action = "WITH CHECK CHECK"
+	}
+	var builder strings.Builder
+	for _, table := range tables {
+		builder.WriteString(fmt.Sprintf("ALTER TABLE %s %s CONSTRAINT ALL;\n", table, action))
+	}
+	return builder.String()
+}
+
+// dataPreamble returns the session settings written once at the top of the data section, in the
+// same batch as the first table. They last for the whole session, so later batches keep them.
+func (m *MSSQLDriver) dataPreamble() string {
+	var statements []string
+	if !m.cfg.RowCounts {
+		// Without it, every INSERT prints a "rows affected" message, which slows clients such as SSMS.
+		statements = append(statements, "SET NOCOUNT ON;")
+	}
+	if m.cfg.XactAbort || m.cfg.DataTransaction {
+		// A single transaction relies on it to roll back on the first failing statement.
+		statements = append(statements, "SET XACT_ABORT ON;")
+	}
+
+	var preamble strings.Builder
+	for _, stmt := range statements {
+		if !slices.Contains(m.cfg.OmitPreamble, stmt) {
+			preamble.WriteString(stmt + "\n")
+		}
+	}
+	if preamble.Len() > 0 {
+		preamble.WriteString("\n")
+	}
+	return preamble.String()
+}
+
+// EstimateRows returns the approximate number of rows of every user table, read from the partition metadata.
+func (m *MSSQLDriver) EstimateRows(ctx context.Context, db *sql.DB) (map[TableName]int64, error) {
+	rows, err := db.QueryContext(ctx, mssqlQueryRowEstimates)
+	if err != nil {
+		return nil, apperrors.New(apperrors.ErrDBQuery, "failed to query row estimates", err)
+	}
+	defer rows.Close()
+
+	estimates := make(map[TableName]int64)
+	for rows.Next() {
+		var schema, table string
+		var count int64
+		if err := rows.Scan(&schema, &table, &count); err != nil {
+			return nil, apperrors.New(apperrors.ErrDBQuery, "failed to scan row estimates", err)
+		}
+		estimates[NewTableName(schema, table)] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperrors.New(apperrors.ErrDBQuery, "error iterating row estimates", err)
+	}
+	return estimates, nil
+}
+
+// CountRows returns the exact number of rows of the given tables, counted with COUNT_BIG(*).
+func (m *MSSQLDriver) CountRows(ctx context.Context, db *sql.DB, tables []TableName) (map[TableName]int64, error) {
+	counts := make(map[TableName]int64, len(tables))
+	progress := m.progress()
+	progress.Start("Counting rows", len(tables))
+	defer progress.Done()
+	for _, table := range tables {
+		var count int64
+		err := withRetry(ctx, m.cfg.Retry, func() error {
+			return db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT_BIG(*) FROM %s", table)).Scan(&count)
+		})
+		if err != nil {
+			return nil, apperrors.New(apperrors.ErrDBQuery, "failed to count rows", err).WithTable(table.String())
+		}
+		counts[table] = count
+		progress.Increment(1)
+	}
+	return counts, nil
+}
+
+// getTableList returns every user table of the current database.
+func (m *MSSQLDriver) getTableList(ctx context.Context, db Querier) ([]TableName, error) {
+	rows, err := db.QueryContext(ctx, tableListQuery)
 	if err != nil {
-		return "", apperrors.New(apperrors.ErrDBQuery, "failed to query table list", err)
+		return nil, apperrors.New(apperrors.ErrDBQuery, "failed to query table list", err)
 	}
 	defer rows.Close()
 
@@ -111,207 +562,348 @@ func (m *MSSQLDriver) DumpData(db *sql.DB, skip []string) (string, error) {
 	for rows.Next() {
 		var schema, table string
 		if err := rows.Scan(&schema, &table); err != nil {
-			return "", apperrors.New(apperrors.ErrDBQuery, "failed to scan table list", err)
+			return nil, apperrors.New(apperrors.ErrDBQuery, "failed to scan table list", err)
+		}
+		if name := NewTableName(schema, table); m.cfg.includesTable(name) {
+			tables = append(tables, name)
 		}
-		fullTableName := NewTableName(schema, table)
-		tables = append(tables, fullTableName)
 	}
 	if err := rows.Err(); err != nil {
-		return "", apperrors.New(apperrors.ErrDBQuery, "error iterating table list", err)
+		return nil, apperrors.New(apperrors.ErrDBQuery, "error iterating table list", err)
 	}
+	return tables, nil
+}
 
-	mappings, err := m.getTableMappings(db)
+// Tables returns the user tables of the database, sorted by name.
+func (m *MSSQLDriver) Tables(ctx context.Context, db *sql.DB) ([]TableName, error) {
+	tables, err := m.getTableList(ctx, db)
 	if err != nil {
-		return "", fmt.Errorf("MSSQL error fetching mappings: %w", err)
+		return nil, err
 	}
+	slices.Sort(tables)
+	return tables, nil
+}
 
-	progressCh := make(chan int, len(tables))
-	errChan := make(chan error, len(tables))
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	var result strings.Builder
+// DatabaseName returns the name of the current database, as DB_NAME() reports it.
+func (m *MSSQLDriver) DatabaseName(ctx context.Context, db *sql.DB) (string, error) {
+	var name string
+	err := withRetry(ctx, m.cfg.Retry, func() error {
+		return db.QueryRowContext(ctx, "SELECT DB_NAME()").Scan(&name)
+	})
+	if err != nil {
+		return "", apperrors.New(apperrors.ErrDBQuery, "error fetching the database name", err)
+	}
+	return name, nil
+}
 
-	// Progress updater goroutine.
-	go func(total int) {
-		processed := 0
-		for p := range progressCh {
-			processed += p
-			// Clear the previous line and print updated progress.
-			fmt.Printf("\033[1A\033[K[Dumping data (%d/%d)]\n", processed, total)
-		}
-	}(len(tables))
+// Dependencies returns the foreign key dependencies of every user table.
+func (m *MSSQLDriver) Dependencies(ctx context.Context, db *sql.DB) (DependencyTree, error) {
+	return m.getDependencyTree(ctx, db)
+}
+
+// getDependencyTree returns the dependency tree of the database, including tables without foreign keys.
+// Tables left out by the configured filters are not part of it.
+func (m *MSSQLDriver) getDependencyTree(ctx context.Context, db Querier) (DependencyTree, error) {
+	deps, err := m.analyzeDependencies(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("MSSQL error analyzing dependencies: %w", err)
+	}
 
-	// Dump each table concurrently with a 1-minute timeout per table.
+	tables, err := m.getTableList(ctx, db)
+	if err != nil {
+		return nil, err
+	}
 	for _, table := range tables {
-		wg.Add(1)
-		go func(tbl TableName) {
-			defer wg.Done()
-			// Create a new context for this cycle with a 1-minute timeout.
-			ctxCycle, cancelCycle := context.WithTimeout(context.Background(), time.Minute)
-			defer cancelCycle()
+		if _, exists := deps[table]; !exists {
+			deps[table] = make([]TableName, 0)
+		}
+	}
+	if err := m.cfg.filterTables(deps); err != nil {
+		return nil, err
+	}
+	return deps, nil
+}
 
-			_, tableName := tbl.GetParts()
-			if slices.Contains(skip, tableName) {
-				progressCh <- 1
-				return
-			}
+// getSortedTables returns every user table ordered so that referenced tables come first.
+func (m *MSSQLDriver) getSortedTables(ctx context.Context, db Querier) ([]TableName, error) {
+	deps, err := m.getDependencyTree(ctx, db)
+	if err != nil {
+		return nil, err
+	}
 
-			// Assuming you update dumpTableData to accept a context:
-			dump, err := m.dumpTableData(ctxCycle, db, tbl.String(), mappings[tbl])
-			if err != nil {
-				errChan <- err
-				return
-			}
-			mu.Lock()
-			result.WriteString(dump)
-			mu.Unlock()
-			progressCh <- 1
-		}(table)
+	sortedTables, err := sortTablesByDependencies(deps)
+	if err != nil {
+		return nil, fmt.Errorf("MSSQL error sorting dependencies: %w", err)
 	}
+	return sortedTables, nil
+}
 
-	wg.Wait()
-	close(progressCh)
-	close(errChan)
-	if err, ok := <-errChan; ok {
-		return "", err
+// insertableColumns returns the columns that can be written by an INSERT, in column order.
+// Computed and rowversion/timestamp columns are left out since the server generates their values.
+// Identity columns are kept and written through SET IDENTITY_INSERT, unless NoIdentityInsert
+// asks for the target to generate fresh values.
+func (m *MSSQLDriver) insertableColumns(colInfo []columnDef) []columnDef {
+	var columns []columnDef
+	for _, col := range colInfo {
+		if col.isComputed || isRowVersion(col) || (col.isIdentity && m.cfg.NoIdentityInsert) {
+			continue
+		}
+		columns = append(columns, col)
 	}
+	// The metadata query already orders the columns, but the SELECT and INSERT lists must not
+	// depend on it.
+	slices.SortStableFunc(columns, func(a, b columnDef) int {
+		return a.columnPosition - b.columnPosition
+	})
+	return columns
+}
+
+// isRowVersion reports whether the column is a rowversion, which sys.types names "timestamp".
+func isRowVersion(cd columnDef) bool {
+	dt := strings.ToLower(cd.dataType)
+	return dt == "rowversion" || dt == "timestamp"
+}
 
-	fmt.Println()
+// isExactNumeric reports whether the column is a decimal, numeric, money or smallmoney, whose
+// values the driver returns as text.
+func isExactNumeric(cd columnDef) bool {
+	switch strings.ToLower(cd.dataType) {
+	case "decimal", "numeric", "money", "smallmoney":
+		return true
+	default:
+		return false
+	}
+}
 
-	return result.String(), nil
+// formatTime returns the literal text of a time value read from a column of the given type.
+// datetime2, datetimeoffset and time keep all seven fractional digits, and datetimeoffset its
+// offset, so they load back unchanged; datetime is only precise to the millisecond anyway.
+func formatTime(dataType string, t time.Time) string {
+	switch strings.ToLower(dataType) {
+	case "date":
+		return t.Format("2006-01-02")
+	case "time":
+		return t.Format("15:04:05.9999999")
+	case "datetime":
+		return t.Format("2006-01-02 15:04:05.999")
+	case "datetime2":
+		return t.Format("2006-01-02 15:04:05.9999999")
+	case "datetimeoffset":
+		return t.Format("2006-01-02 15:04:05.9999999 -07:00")
+	default:
+		return t.Format("2006-01-02 15:04:05")
+	}
 }
 
 type insertBuffer []string
 
-func (b *insertBuffer) flush() string {
-	// Return empty string if there's nothing to flush.
+// flush returns the buffered rows as one INSERT statement starting with head, and empties the buffer.
+func (b *insertBuffer) flush(head string) string {
+	// Return empty string if there's nothing to flush, so no INSERT without values is produced.
 	if b == nil || len(*b) == 0 {
 		return ""
 	}
 	// Join the buffered values.
-	result := strings.Join(*b, ",\n") + ";\n"
+	result := head + strings.Join(*b, ",\n") + ";\n"
 	// Reset the underlying slice.
 	*b = (*b)[:0]
 	return result
 }
 
-// dumpTableData generates INSERT statements for all rows of a single table.
-func (m *MSSQLDriver) dumpTableData(ctx context.Context, db *sql.DB, table string, colInfo []columnDef) (string, error) {
-	query := fmt.Sprintf("SELECT * FROM %s", table)
-	rows, err := db.QueryContext(ctx, query)
-	if err != nil {
-		return "", apperrors.New(apperrors.ErrDataDump, fmt.Sprintf("failed to query data for table %s", table), err)
+// selectRowsQuery builds the query reading the rows of a table to dump or copy,
+// applying the configured row filter and limit, and filter when it is not empty.
+func (m *MSSQLDriver) selectRowsQuery(table, colList string, primaryKey []string, filter string) string {
+	top := ""
+	if m.cfg.Limit > 0 {
+		top = fmt.Sprintf("TOP (%d) ", m.cfg.Limit)
 	}
-	defer rows.Close()
-
-	columns, err := rows.Columns()
-	if err != nil {
-		return "", apperrors.New(apperrors.ErrDataDump, fmt.Sprintf("failed to get columns for table %s", table), err)
+	query := fmt.Sprintf("SELECT %s%s FROM %s", top, colList, table)
+	predicate, ok := m.cfg.Where[TableName(table)]
+	switch {
+	case ok && filter != "":
+		query += fmt.Sprintf(" WHERE (%s) AND (%s)", predicate, filter)
+	case ok:
+		query += " WHERE " + predicate
+	case filter != "":
+		query += " WHERE " + filter
 	}
+	if len(primaryKey) > 0 {
+		var keyNames []string
+		for _, col := range primaryKey {
+			keyNames = append(keyNames, FormatObjectName(col))
+		}
+		query += " ORDER BY " + strings.Join(keyNames, ", ")
+	}
+	return query
+}
 
-	var builder, insertStmtBuilder strings.Builder
-	builder.WriteString(fmt.Sprintf("-- Data dump for table: %s\n", table))
-	// Build column list (formatted with square brackets)
+// dumpTableData generates INSERT statements for all rows of a single table.
+// Rows are ordered by the primary key columns so repeated dumps are identical;
+// tables without a primary key are read in whatever order the server returns.
+// onRow is called for every scanned row, for progress reporting.
+func (m *MSSQLDriver) dumpTableData(ctx context.Context, db Querier, table string, colInfo []columnDef, primaryKey []string, onRow func()) (string, error) {
+	// Build column list (formatted with square brackets) from the metadata rather than SELECT *,
+	// so that columns the server generates itself are left out of both the SELECT and the INSERT.
+	columns := m.insertableColumns(colInfo)
+	if len(columns) == 0 {
+		// Every column is generated by the server, so there is nothing to insert.
+		return "", nil
+	}
 	var colNames []string
 	for _, col := range columns {
-		colNames = append(colNames, FormatObjectName(col))
+		colNames = append(colNames, FormatObjectName(col.columnName))
 	}
 	colList := strings.Join(colNames, ", ")
-	batch := 50
-	batchCount := batch
+	masks := make([]Masker, len(columns))
+	for i, col := range columns {
+		masks[i] = m.cfg.Mask.forColumn(NewTableName(col.schema, col.table), col.columnName)
+	}
+
+	var builder, insertStmtBuilder strings.Builder
+	batch := m.insertBatchSize()
+	dumped := 0
 	insertHead := fmt.Sprintf("INSERT INTO %s (%s) VALUES \n", table, colList)
 	// Process each row
 	insertValues := make(insertBuffer, 0, batch)
-	for rows.Next() {
-		// Optional: check for context cancellation
-		select {
-		case <-ctx.Done():
-			return "", ctx.Err()
-		default:
+	interrupted := false
+
+	// readRows adds the rows a query returns to the INSERT statements. It is not retried on its
+	// own, as dumpData retries the whole table.
+	readRows := func(query string) error {
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return apperrors.New(apperrors.ErrDataDump, "failed to query data", err).WithTable(table)
 		}
+		defer rows.Close()
 
-		if batchCount == batch {
-			insertStmtBuilder.WriteString(insertHead)
-		}
-		// Prepare a slice for the row values.
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return "", apperrors.New(apperrors.ErrDataDump, fmt.Sprintf("failed to scan row for table %s", table), err)
-		}
-
-		// Format each value appropriately.
-		var valueStrs []string
-		for i, val := range values {
-			// Check if the current column (by index) is a geography type.
-			if len(colInfo) > i && strings.EqualFold(colInfo[i].dataType, "geography") {
-				// TODO: Implement geography type handling.
-				valueStrs = append(valueStrs, "NULL")
-				// // Expecting v to be []byte for geography. Convert to hex.
-				// b, ok := val.([]byte)
-				// if !ok {
-				// 	// Fallback to a NULL if conversion fails.
-				// 	valueStrs = append(valueStrs, "NULL")
-				// 	continue
-				// }
-				// hexVal := fmt.Sprintf("%X", b)
-				// // Use SQL Server's geography::STGeomFromWKB function.
-				// valueStrs = append(valueStrs, fmt.Sprintf("geography::STGeomFromWKB(0x%s,4326)", hexVal))
-				continue
+		for rows.Next() {
+			if m.cfg.Limit > 0 && dumped >= m.cfg.Limit {
+				break
+			}
+			// On cancellation, stop after the rows read so far and still emit valid statements for them.
+			select {
+			case <-ctx.Done():
+				if !errors.Is(ctx.Err(), context.Canceled) {
+					return ctx.Err()
+				}
+				interrupted = true
+			default:
+			}
+			if interrupted {
+				break
 			}
-			// Normal conversion for other types.
-			if val == nil {
-				valueStrs = append(valueStrs, "NULL")
-			} else {
-				switch v := val.(type) {
-				case []byte:
-					// Convert []byte to string, escape single quotes.
-					str := strings.ReplaceAll(string(v), "'", "''")
-					valueStrs = append(valueStrs, fmt.Sprintf("'%s'", str))
-				case string:
-					escaped := strings.ReplaceAll(v, "'", "''")
-					valueStrs = append(valueStrs, fmt.Sprintf("'%s'", escaped))
-				case time.Time:
-					formattedTime := v.Format("2006-01-02 15:04:05")
-					valueStrs = append(valueStrs, fmt.Sprintf("'%s'", formattedTime))
-				case bool:
-					if v {
-						valueStrs = append(valueStrs, "1")
-					} else {
-						valueStrs = append(valueStrs, "0")
+
+			// Prepare a slice for the row values.
+			values := make([]interface{}, len(columns))
+			valuePtrs := make([]interface{}, len(columns))
+			for i := range values {
+				valuePtrs[i] = &values[i]
+			}
+
+			if err := rows.Scan(valuePtrs...); err != nil {
+				return apperrors.New(apperrors.ErrDataDump, "failed to scan row", err).WithTable(table)
+			}
+			onRow()
+			dumped++
+
+			// Format each value appropriately.
+			var valueStrs []string
+			for i, val := range values {
+				// Check if the current column (by index) is a geography type.
+				if strings.EqualFold(columns[i].dataType, "geography") {
+					// TODO: Implement geography type handling.
+					valueStrs = append(valueStrs, "NULL")
+					// // Expecting v to be []byte for geography. Convert to hex.
+					// b, ok := val.([]byte)
+					// if !ok {
+					// 	// Fallback to a NULL if conversion fails.
+					// 	valueStrs = append(valueStrs, "NULL")
+					// 	continue
+					// }
+					// hexVal := fmt.Sprintf("%X", b)
+					// // Use SQL Server's geography::STGeomFromWKB function.
+					// valueStrs = append(valueStrs, fmt.Sprintf("geography::STGeomFromWKB(0x%s,4326)", hexVal))
+					continue
+				}
+				// Normal conversion for other types.
+				if val == nil {
+					valueStrs = append(valueStrs, "NULL")
+				} else if masks[i] != nil {
+					valueStrs = append(valueStrs, maskValue(masks[i], val))
+				} else {
+					switch v := val.(type) {
+					case []byte:
+						// The driver returns decimal and money values as text, but a quoted string does not load
+						// into such a column in every mode, so they are written as the numeric literal they are.
+						if isExactNumeric(columns[i]) {
+							valueStrs = append(valueStrs, string(v))
+							continue
+						}
+						// Convert []byte to string, escape single quotes.
+						str := strings.ReplaceAll(string(v), "'", "''")
+						valueStrs = append(valueStrs, fmt.Sprintf("'%s'", str))
+					case string:
+						escaped := strings.ReplaceAll(v, "'", "''")
+						valueStrs = append(valueStrs, fmt.Sprintf("'%s'", escaped))
+					case time.Time:
+						formattedTime := formatTime(columns[i].dataType, v)
+						valueStrs = append(valueStrs, fmt.Sprintf("'%s'", formattedTime))
+					case bool:
+						if v {
+							valueStrs = append(valueStrs, "1")
+						} else {
+							valueStrs = append(valueStrs, "0")
+						}
+					default:
+						valueStrs = append(valueStrs, fmt.Sprint(v))
 					}
-				default:
-					valueStrs = append(valueStrs, fmt.Sprint(v))
 				}
 			}
-		}
 
-		// Build the INSERT statement.
-		batchCount--
-		insertValues = append(insertValues, fmt.Sprintf("(%s)", strings.Join(valueStrs, ", ")))
+			// Build the INSERT statement.
+			insertValues = append(insertValues, fmt.Sprintf("(%s)", strings.Join(valueStrs, ", ")))
 
-		if batchCount <= 0 {
-			insertStmt := insertValues.flush()
-			batchCount = batch
-			insertStmtBuilder.WriteString(insertStmt)
+			// The head is written together with the values of its batch, so a full batch here and the
+			// partial one flushed after the loop are both complete statements, whatever the row count.
+			if len(insertValues) >= batch {
+				insertStmtBuilder.WriteString(insertValues.flush(insertHead))
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			if !errors.Is(ctx.Err(), context.Canceled) {
+				return apperrors.New(apperrors.ErrDataDump, "error iterating rows", err).WithTable(table)
+			}
+			interrupted = true
 		}
+		return nil
 	}
 
-	if err := rows.Err(); err != nil {
-		return "", apperrors.New(apperrors.ErrDataDump, fmt.Sprintf("error iterating rows for table %s", table), err)
+	// A sampled table is read one chunk of its sampled keys at a time, so no query gets too long.
+	filters := []string{""}
+	if chunks, ok := m.sample[TableName(table)]; ok {
+		filters = chunks
 	}
+	for _, filter := range filters {
+		if interrupted || (m.cfg.Limit > 0 && dumped >= m.cfg.Limit) {
+			break
+		}
+		if err := readRows(m.selectRowsQuery(table, colList, primaryKey, filter)); err != nil {
+			return "", err
+		}
+	}
+
+	insertStmtBuilder.WriteString(insertValues.flush(insertHead))
 
-	if len(insertValues) > 0 {
-		insertStmt := insertValues.flush()
-		insertStmtBuilder.WriteString(insertStmt)
+	// An empty table gets no section at all, unless the interruption marker must be written.
+	if dumped == 0 && !interrupted {
+		return "", nil
 	}
+	builder.WriteString(fmt.Sprintf("-- Data dump for table: %s\n", table))
 
+	// IDENTITY_INSERT is only needed (and only allowed) when the identity column is being inserted.
 	isIdentity := false
-	for _, col := range colInfo {
+	for _, col := range columns {
 		if col.isIdentity {
 			isIdentity = true
 			break
@@ -326,139 +918,369 @@ func (m *MSSQLDriver) dumpTableData(ctx context.Context, db *sql.DB, table strin
 		builder.WriteString(insertStmtBuilder.String())
 	}
 
-	// Separate dumps for readability.
-	builder.WriteString("\nGO;\n\n")
+	if interrupted {
+		builder.WriteString("-- Interrupted: the remaining rows of this table were not dumped.\n")
+	}
+
+	// Separate dumps for readability. In a single transaction the tables share one batch instead, as
+	// a failure aborts only its own batch under XACT_ABORT and the following batches would still run.
+	if m.cfg.DataTransaction {
+		builder.WriteString("\n")
+	} else {
+		builder.WriteString(BatchSeparator)
+	}
+	if interrupted {
+		return builder.String(), context.Canceled
+	}
+	return builder.String(), nil
+}
+
+// DumpTable returns the requested parts of a single table's dump.
+// With withDependencies, the tables it references (directly or not) are dumped as well, parents first.
+func (m *MSSQLDriver) DumpTable(ctx context.Context, db *sql.DB, name string, parts DumpParts, withDependencies bool) (string, error) {
+	target := ParseTableName(name)
+	deps, err := m.getDependencyTree(ctx, db)
+	if err != nil {
+		return "", err
+	}
+	if _, exists := deps[target]; !exists {
+		msg := fmt.Sprintf("table %s does not exist", target)
+		return "", apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+	}
+
+	selected := []TableName{target}
+	if withDependencies {
+		selected = dependencyClosure(deps, target)
+	}
+	sortedTables, err := sortTablesByDependencies(deps)
+	if err != nil {
+		return "", fmt.Errorf("MSSQL error sorting dependencies: %w", err)
+	}
+	var tables []TableName
+	for _, table := range sortedTables {
+		if slices.Contains(selected, table) {
+			tables = append(tables, table)
+		}
+	}
+
+	// On interruption, the statements produced so far are returned along with the error.
+	var builder strings.Builder
+	if parts.Schema {
+		schema, err := m.dumpSchema(ctx, db, tables)
+		builder.WriteString(schema + m.dialect().BatchSeparator())
+		if err != nil {
+			return builder.String(), err
+		}
+	}
+	if parts.Data {
+		if err := m.dumpData(ctx, db, tables, nil, &builder); err != nil {
+			return builder.String(), err
+		}
+	}
+	if parts.Constraints {
+		constraints, err := m.dumpConstraints(ctx, db, func(t TableName) bool {
+			return slices.Contains(tables, t)
+		})
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(constraints + m.dialect().BatchSeparator())
+	}
 	return builder.String(), nil
 }
 
-// DumpConstraints returns a placeholder string for the constraints dump.
-// In a real implementation, you might query INFORMATION_SCHEMA for keys, indexes, etc.
-func (m *MSSQLDriver) DumpConstraints(db *sql.DB) (string, error) {
+// DumpConstraints returns the primary keys, unique constraints, indexes and foreign keys of the
+// tables kept by the Config filters, foreign keys last.
+func (m *MSSQLDriver) DumpConstraints(ctx context.Context, db *sql.DB) (string, error) {
+	return m.dumpConstraints(ctx, db, m.cfg.includesTable)
+}
+
+// dumpConstraints emits the constraints of the tables accepted by include, or of every table when include is nil.
+func (m *MSSQLDriver) dumpConstraints(ctx context.Context, db Querier, include func(TableName) bool) (string, error) {
+	constraints, err := m.getConstraints(ctx, db, include)
+	if err != nil {
+		return "", err
+	}
+
+	quote := m.dialect().QuoteName
+	_, native := m.dialect().(mssqlDialect)
+	progress := m.progress()
 	var builder strings.Builder
 	builder.WriteString("-- Constraints Dump\n\n")
 
-	// --- Primary Keys ---
-	const queryPrimaryKeys = `
-SELECT 
-    tc.TABLE_SCHEMA,
-    tc.TABLE_NAME,
-    tc.CONSTRAINT_NAME,
-    kcu.COLUMN_NAME,
-    kcu.ORDINAL_POSITION
-FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS AS tc
-JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE AS kcu 
-    ON tc.CONSTRAINT_NAME = kcu.CONSTRAINT_NAME
-WHERE tc.CONSTRAINT_TYPE = 'PRIMARY KEY'
-ORDER BY tc.TABLE_SCHEMA, tc.TABLE_NAME, tc.CONSTRAINT_NAME, kcu.ORDINAL_POSITION;
-`
-	rows, err := db.Query(queryPrimaryKeys)
+	// Build primary key ALTER statements.
+	progress.Start("Dumping PKs", len(constraints.primaryKeys))
+	for _, pk := range constraints.primaryKeys {
+		// Use the constraint name as provided.
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;\n",
+			quote(pk.schema, pk.table), quote(pk.constraintName), pk.definition(quote, native))
+		builder.WriteString(stmt)
+		progress.Increment(1)
+	}
+
+	progress.Done()
+	builder.WriteString("\n")
+
+	// Build unique constraint ALTER statements. They come before the foreign keys, which may reference them.
+	if len(constraints.uniqueConstraints) > 0 {
+		progress.Start("Dumping unique constraints", len(constraints.uniqueConstraints))
+		for _, uq := range constraints.uniqueConstraints {
+			stmt := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;\n",
+				quote(uq.schema, uq.table), quote(uq.constraintName), uq.definition(quote, native))
+			builder.WriteString(stmt)
+			progress.Increment(1)
+		}
+
+		progress.Done()
+		builder.WriteString("\n")
+	}
+
+	// Build the CREATE INDEX statements of the indexes that back no constraint.
+	if len(constraints.indexes) > 0 {
+		progress.Start("Dumping indexes", len(constraints.indexes))
+		for _, idx := range constraints.indexes {
+			builder.WriteString(m.createIndexStatement(idx))
+			progress.Increment(1)
+		}
+
+		progress.Done()
+		builder.WriteString("\n")
+	}
+
+	// Build foreign key ALTER statements. WITH NOCHECK skips validating the existing rows.
+	check := ""
+	if m.cfg.FKNoCheck {
+		check = " WITH NOCHECK"
+	}
+	progress.Start("Dumping FKs", len(constraints.foreignKeys))
+	for _, fk := range constraints.foreignKeys {
+		stmt := fmt.Sprintf("ALTER TABLE %s%s ADD CONSTRAINT %s %s;\n",
+			quote(fk.childSchema, fk.childTable), check, quote(fk.constraintName), fk.definition(quote))
+		builder.WriteString(stmt)
+		progress.Increment(1)
+	}
+
+	progress.Done()
+
+	// Validate the existing rows afterwards, so the keys are trusted by the optimizer again.
+	if m.cfg.FKNoCheck && m.cfg.FKRecheck && len(constraints.foreignKeys) > 0 {
+		builder.WriteString("\n")
+		for _, fk := range constraints.foreignKeys {
+			builder.WriteString(fmt.Sprintf("ALTER TABLE %s WITH CHECK CHECK CONSTRAINT %s;\n",
+				quote(fk.childSchema, fk.childTable), quote(fk.constraintName)))
+		}
+	}
+	return builder.String(), nil
+}
+
+// keyConstraintInfo is a primary key or unique constraint, with its columns in key order.
+type keyConstraintInfo struct {
+	schema         string
+	table          string
+	constraintName string
+	kind           string // "PRIMARY KEY" or "UNIQUE".
+	index          string // "CLUSTERED" or "NONCLUSTERED", from sys.indexes.type_desc of the backing index.
+	columns        []indexColumn
+	fillFactor     int // 0 for the server default.
+}
+
+// definition returns the constraint as written after its name, e.g. "PRIMARY KEY CLUSTERED ([Id])".
+// The index kind, descending keys and FILLFACTOR are SQL Server storage settings, which are only
+// written when native is set. The kind is then always written, since the default depends on
+// whether the table already has a clustered index.
+func (kc keyConstraintInfo) definition(quote func(...string) string, native bool) string {
+	keys := make([]string, len(kc.columns))
+	for i, col := range kc.columns {
+		keys[i] = quote(col.name)
+		if native && col.descending {
+			keys[i] += " DESC"
+		}
+	}
+	if !native {
+		return fmt.Sprintf("%s (%s)", kc.kind, strings.Join(keys, ", "))
+	}
+	def := fmt.Sprintf("%s %s (%s)", kc.kind, kc.index, strings.Join(keys, ", "))
+	if kc.fillFactor > 0 {
+		def += fmt.Sprintf(" WITH (FILLFACTOR = %d)", kc.fillFactor)
+	}
+	return def
+}
+
+// foreignKeyInfo is a foreign key constraint, with its columns in key order.
+type foreignKeyInfo struct {
+	childSchema    string
+	childTable     string
+	constraintName string
+	parentSchema   string
+	parentTable    string
+	childColumns   []string
+	parentColumns  []string
+	updateRule     string
+	deleteRule     string
+}
+
+// definition returns the constraint as written after its name, from FOREIGN KEY to the referential
+// actions. NO ACTION is the default, so its ON UPDATE or ON DELETE clause is left out.
+func (fk foreignKeyInfo) definition(quote func(...string) string) string {
+	var childCols, parentCols []string
+	for _, col := range fk.childColumns {
+		childCols = append(childCols, quote(col))
+	}
+	for _, col := range fk.parentColumns {
+		parentCols = append(parentCols, quote(col))
+	}
+	def := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)",
+		strings.Join(childCols, ", "),
+		quote(fk.parentSchema, fk.parentTable),
+		strings.Join(parentCols, ", "),
+	)
+	if action := referentialAction(fk.updateRule); action != "NO ACTION" {
+		def += " ON UPDATE " + action
+	}
+	if action := referentialAction(fk.deleteRule); action != "NO ACTION" {
+		def += " ON DELETE " + action
+	}
+	return def
+}
+
+// referentialAction returns a foreign key rule as its T-SQL keywords, whatever its case
+// or separator, e.g. "set_null" becomes "SET NULL". Anything else, such as RESTRICT, which T-SQL
+// lacks, means NO ACTION.
+func referentialAction(rule string) string {
+	action := strings.Join(strings.Fields(strings.ToUpper(strings.ReplaceAll(rule, "_", " "))), " ")
+	switch action {
+	case "CASCADE", "SET NULL", "SET DEFAULT":
+		return action
+	default:
+		return "NO ACTION"
+	}
+}
+
+// constraintSet holds the constraints of a database, by kind.
+type constraintSet struct {
+	primaryKeys       []keyConstraintInfo
+	uniqueConstraints []keyConstraintInfo
+	indexes           []indexInfo
+	foreignKeys       []foreignKeyInfo
+}
+
+// getConstraints returns the primary keys, unique constraints, indexes and foreign keys of the tables accepted
+// by include, or of every table when include is nil, ordered by schema, table and constraint name so
+// dumps are repeatable. The queries are independent, so on a connection pool they run concurrently;
+// a single connection or transaction cannot run them at the same time.
+func (m *MSSQLDriver) getConstraints(ctx context.Context, db Querier, include func(TableName) bool) (constraintSet, error) {
+	var constraints constraintSet
+	var pkErr, uqErr, ixErr error
+	var wg sync.WaitGroup
+	_, pool := db.(*sql.DB)
+	run := func(query func()) {
+		if !pool {
+			query()
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			query()
+		}()
+	}
+	run(func() {
+		constraints.primaryKeys, pkErr = m.getKeyConstraints(ctx, db, mssqlQueryPrimaryKeys, "PRIMARY KEY", include)
+	})
+	run(func() {
+		constraints.uniqueConstraints, uqErr = m.getKeyConstraints(ctx, db, mssqlQueryUniqueConstraints, "UNIQUE", include)
+	})
+	run(func() {
+		constraints.indexes, ixErr = m.getIndexes(ctx, db, include)
+	})
+	foreignKeys, fkErr := m.getForeignKeys(ctx, db, include)
+	wg.Wait()
+
+	if err := errors.Join(pkErr, uqErr, ixErr, fkErr); err != nil {
+		return constraintSet{}, err
+	}
+	constraints.foreignKeys = foreignKeys
+	return constraints, nil
+}
+
+// getKeyConstraints returns the constraints of the given kind that query lists, for the tables
+// accepted by include, in query order.
+func (m *MSSQLDriver) getKeyConstraints(ctx context.Context, db Querier, query, kind string, include func(TableName) bool) ([]keyConstraintInfo, error) {
+	name := strings.ToLower(kind)
+	var rows *sql.Rows
+	err := withRetry(ctx, m.cfg.Retry, func() error {
+		var err error
+		rows, err = db.QueryContext(ctx, query)
+		return err
+	})
 	if err != nil {
-		return "", apperrors.New(apperrors.ErrDBQuery, "error fetching primary key constraints", err)
+		return nil, apperrors.New(apperrors.ErrDBQuery, fmt.Sprintf("error fetching %s constraints", name), err)
 	}
 	defer rows.Close()
 
-	// Group primary key columns by a composite key: schema.table.constraint.
-	type primaryKeyInfo struct {
-		schema         string
-		table          string
-		constraintName string
-		columns        []string
-	}
-	pkMap := make(map[string]*primaryKeyInfo)
+	// Rows come ordered by constraint, so the columns of a key are consecutive.
+	var constraints []keyConstraintInfo
 	for rows.Next() {
-		var schema, table, constraintName, column string
-		var ordinal int // not used directly but needed for ordering
-		if err := rows.Scan(&schema, &table, &constraintName, &column, &ordinal); err != nil {
-			return "", apperrors.New(apperrors.ErrDBQuery, "error scanning primary key row", err)
-		}
-		key := fmt.Sprintf("%s.%s.%s", schema, table, constraintName)
-		if pk, exists := pkMap[key]; exists {
-			pk.columns = append(pk.columns, column)
+		var schema, table, constraintName, column, index string
+		var ordinal, fillFactor int // The ordinal is not used directly but needed for ordering.
+		var descending bool
+		if err := rows.Scan(&schema, &table, &constraintName, &column, &ordinal, &index, &fillFactor, &descending); err != nil {
+			return nil, apperrors.New(apperrors.ErrDBQuery, fmt.Sprintf("error scanning %s row", name), err)
+		}
+		if include != nil && !include(NewTableName(schema, table)) {
+			continue
+		}
+		key := indexColumn{name: column, descending: descending}
+		if n := len(constraints); n > 0 && constraints[n-1].schema == schema &&
+			constraints[n-1].table == table && constraints[n-1].constraintName == constraintName {
+			constraints[n-1].columns = append(constraints[n-1].columns, key)
 		} else {
-			pkMap[key] = &primaryKeyInfo{
+			constraints = append(constraints, keyConstraintInfo{
 				schema:         schema,
 				table:          table,
 				constraintName: constraintName,
-				columns:        []string{column},
-			}
+				kind:           kind,
+				index:          index,
+				columns:        []indexColumn{key},
+				fillFactor:     fillFactor,
+			})
 		}
 	}
 	if err := rows.Err(); err != nil {
-		return "", apperrors.New(apperrors.ErrDBQuery, "error iterating primary key rows", err)
-	}
-
-	// Build primary key ALTER statements.
-	counter := 0
-	for _, pk := range pkMap {
-		counter++
-		fmt.Printf("\033[1A\033[K[Dumping PKs (%d/%d)]\n", counter, len(pkMap))
-		fullTableName := FormatObjectName(pk.schema, pk.table)
-		// Use the constraint name as provided.
-		constraintName := FormatObjectName(pk.constraintName)
-		var colNames []string
-		for _, col := range pk.columns {
-			colNames = append(colNames, FormatObjectName(col))
-		}
-		stmt := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s PRIMARY KEY (%s);\n",
-			fullTableName, constraintName, strings.Join(colNames, ", "))
-		builder.WriteString(stmt)
+		return nil, apperrors.New(apperrors.ErrDBQuery, fmt.Sprintf("error iterating %s rows", name), err)
 	}
+	return constraints, nil
+}
 
-	println()
-	builder.WriteString("\n")
-
-	// --- Foreign Keys ---
-	// This query retrieves foreign key details including column-level information.
-	const queryForeignKeys = `
-SELECT 
-    fk.TABLE_SCHEMA AS ChildSchema,
-    fk.TABLE_NAME AS ChildTable,
-    fk.CONSTRAINT_NAME AS ForeignKey,
-    pk.TABLE_SCHEMA AS ParentSchema, 
-    pk.TABLE_NAME AS ParentTable,
-    fkc.COLUMN_NAME AS ChildColumn,
-    pkc.COLUMN_NAME AS ParentColumn,
-    rc.UPDATE_RULE,
-    rc.DELETE_RULE,
-    fkc.ORDINAL_POSITION
-FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc
-JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS fk ON rc.CONSTRAINT_NAME = fk.CONSTRAINT_NAME
-JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS pk ON rc.UNIQUE_CONSTRAINT_NAME = pk.CONSTRAINT_NAME
-JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE fkc ON fk.CONSTRAINT_NAME = fkc.CONSTRAINT_NAME
-JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE pkc ON pk.CONSTRAINT_NAME = pkc.CONSTRAINT_NAME 
-    AND fkc.ORDINAL_POSITION = pkc.ORDINAL_POSITION
-ORDER BY fk.TABLE_SCHEMA, fk.TABLE_NAME, fk.CONSTRAINT_NAME, fkc.ORDINAL_POSITION;
-`
-	fkRows, err := db.Query(queryForeignKeys)
+// getForeignKeys returns the foreign keys of the tables accepted by include, in query order.
+func (m *MSSQLDriver) getForeignKeys(ctx context.Context, db Querier, include func(TableName) bool) ([]foreignKeyInfo, error) {
+	var fkRows *sql.Rows
+	err := withRetry(ctx, m.cfg.Retry, func() error {
+		var err error
+		fkRows, err = db.QueryContext(ctx, mssqlQueryForeignKeys)
+		return err
+	})
 	if err != nil {
-		return "", apperrors.New(apperrors.ErrDBQuery, "error fetching foreign key constraints", err)
+		return nil, apperrors.New(apperrors.ErrDBQuery, "error fetching foreign key constraints", err)
 	}
 	defer fkRows.Close()
 
-	type foreignKeyInfo struct {
-		childSchema    string
-		childTable     string
-		constraintName string
-		parentSchema   string
-		parentTable    string
-		childColumns   []string
-		parentColumns  []string
-		updateRule     string
-		deleteRule     string
-	}
-	fkMap := make(map[string]*foreignKeyInfo)
+	var foreignKeys []foreignKeyInfo
 	for fkRows.Next() {
 		var childSchema, childTable, constraintName, parentSchema, parentTable, childColumn, parentColumn, updateRule, deleteRule string
 		var ordinal int
 		if err := fkRows.Scan(&childSchema, &childTable, &constraintName, &parentSchema, &parentTable, &childColumn, &parentColumn, &updateRule, &deleteRule, &ordinal); err != nil {
-			return "", apperrors.New(apperrors.ErrDBQuery, "error scanning foreign key row", err)
+			return nil, apperrors.New(apperrors.ErrDBQuery, "error scanning foreign key row", err)
+		}
+		if include != nil && !include(NewTableName(childSchema, childTable)) {
+			continue
 		}
-		key := fmt.Sprintf("%s.%s.%s", childSchema, childTable, constraintName)
-		if fk, exists := fkMap[key]; exists {
-			fk.childColumns = append(fk.childColumns, childColumn)
-			fk.parentColumns = append(fk.parentColumns, parentColumn)
+		if n := len(foreignKeys); n > 0 && foreignKeys[n-1].childSchema == childSchema &&
+			foreignKeys[n-1].childTable == childTable && foreignKeys[n-1].constraintName == constraintName {
+			foreignKeys[n-1].childColumns = append(foreignKeys[n-1].childColumns, childColumn)
+			foreignKeys[n-1].parentColumns = append(foreignKeys[n-1].parentColumns, parentColumn)
 		} else {
-			fkMap[key] = &foreignKeyInfo{
+			foreignKeys = append(foreignKeys, foreignKeyInfo{
 				childSchema:    childSchema,
 				childTable:     childTable,
 				constraintName: constraintName,
@@ -468,42 +1290,13 @@ ORDER BY fk.TABLE_SCHEMA, fk.TABLE_NAME, fk.CONSTRAINT_NAME, fkc.ORDINAL_POSITIO
 				parentColumns:  []string{parentColumn},
 				updateRule:     updateRule,
 				deleteRule:     deleteRule,
-			}
+			})
 		}
 	}
 	if err := fkRows.Err(); err != nil {
-		return "", apperrors.New(apperrors.ErrDBQuery, "error iterating foreign key rows", err)
-	}
-
-	// Build foreign key ALTER statements.
-	counter = 0
-	for _, fk := range fkMap {
-		counter++
-		fmt.Printf("\033[1A\033[K[Dumping FKs (%d/%d)]\n", counter, len(fkMap))
-		childTableName := FormatObjectName(fk.childSchema, fk.childTable)
-		parentTableName := FormatObjectName(fk.parentSchema, fk.parentTable)
-		constraintName := FormatObjectName(fk.constraintName)
-		var childCols, parentCols []string
-		for _, col := range fk.childColumns {
-			childCols = append(childCols, FormatObjectName(col))
-		}
-		for _, col := range fk.parentColumns {
-			parentCols = append(parentCols, FormatObjectName(col))
-		}
-		stmt := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s) ON UPDATE %s ON DELETE %s;\n",
-			childTableName,
-			constraintName,
-			strings.Join(childCols, ", "),
-			parentTableName,
-			strings.Join(parentCols, ", "),
-			fk.updateRule,
-			fk.deleteRule,
-		)
-		builder.WriteString(stmt)
+		return nil, apperrors.New(apperrors.ErrDBQuery, "error iterating foreign key rows", err)
 	}
-
-	println()
-	return builder.String(), nil
+	return foreignKeys, nil
 }
 
 type columnDef struct {
@@ -518,12 +1311,43 @@ type columnDef struct {
 	isNullable     bool
 	isIdentity     bool
 	isComputed     bool
+	collation      string // Set only when it differs from the database default.
+}
+
+// getPrimaryKeyColumns returns the primary key columns of every table, in key order.
+func (m *MSSQLDriver) getPrimaryKeyColumns(ctx context.Context, db Querier) (map[TableName][]string, error) {
+	var rows *sql.Rows
+	err := withRetry(ctx, m.cfg.Retry, func() error {
+		var err error
+		rows, err = db.QueryContext(ctx, mssqlQueryPrimaryKeys)
+		return err
+	})
+	if err != nil {
+		return nil, apperrors.New(apperrors.ErrDBQuery, "error fetching primary key columns", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[TableName][]string)
+	for rows.Next() {
+		var schema, table, constraintName, column, index string
+		var ordinal, fillFactor int
+		var descending bool
+		if err := rows.Scan(&schema, &table, &constraintName, &column, &ordinal, &index, &fillFactor, &descending); err != nil {
+			return nil, apperrors.New(apperrors.ErrDBQuery, "error scanning primary key column", err)
+		}
+		name := NewTableName(schema, table)
+		keys[name] = append(keys[name], column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperrors.New(apperrors.ErrDBQuery, "error iterating primary key columns", err)
+	}
+	return keys, nil
 }
 
-func (m *MSSQLDriver) getTableMappings(db *sql.DB) (TableMapping, error) {
+func (m *MSSQLDriver) getTableMappings(ctx context.Context, db Querier) (TableMapping, error) {
 	query := mssqlQueryTableMappings
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, apperrors.New(apperrors.ErrDBQuery, "error fetching table structures", err)
 	}
@@ -532,6 +1356,7 @@ func (m *MSSQLDriver) getTableMappings(db *sql.DB) (TableMapping, error) {
 	tableMap := make(TableMapping)
 	for rows.Next() {
 		var cd columnDef
+		var collation sql.NullString
 
 		err := rows.Scan(
 			&cd.schema,
@@ -545,10 +1370,12 @@ func (m *MSSQLDriver) getTableMappings(db *sql.DB) (TableMapping, error) {
 			&cd.isNullable,
 			&cd.isIdentity,
 			&cd.isComputed,
+			&collation,
 		)
 		if err != nil {
 			return nil, apperrors.New(apperrors.ErrDBQuery, "error scanning table structures", err)
 		}
+		cd.collation = collation.String
 		key := NewTableName(cd.schema, cd.table)
 		tableMap[key] = append(tableMap[key], cd)
 	}
@@ -557,16 +1384,30 @@ func (m *MSSQLDriver) getTableMappings(db *sql.DB) (TableMapping, error) {
 		return nil, apperrors.New(apperrors.ErrDBQuery, "error iterating table structures", err)
 	}
 
+	// The query orders the columns already, but the CREATE TABLE must not depend on it.
+	for _, columns := range tableMap {
+		slices.SortStableFunc(columns, func(a, b columnDef) int {
+			return a.columnPosition - b.columnPosition
+		})
+	}
 	return tableMap, nil
 }
 
 func (m *MSSQLDriver) assembleCreateStatements(tm TableMapping) (string, error) {
 	var builder strings.Builder
 	for key, columns := range tm {
-		builder.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", key))
+		// With IfNotExists, the statement is indented one level inside the guard.
+		indent := ""
+		if m.cfg.IfNotExists {
+			schema, table := key.GetParts()
+			builder.WriteString(fmt.Sprintf("IF NOT EXISTS (SELECT 1 FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = '%s' AND TABLE_NAME = '%s')\nBEGIN\n",
+				strings.ReplaceAll(schema, "'", "''"), strings.ReplaceAll(table, "'", "''")))
+			indent = util.TabSpace
+		}
+		builder.WriteString(fmt.Sprintf("%sCREATE TABLE %s (\n", indent, m.dialect().QuoteName(key.GetParts())))
 
 		for i, col := range columns {
-			builder.WriteString(util.TabSpace)
+			builder.WriteString(indent + util.TabSpace)
 
 			colDef := m.buildColumnDefinition(col)
 
@@ -575,26 +1416,35 @@ func (m *MSSQLDriver) assembleCreateStatements(tm TableMapping) (string, error)
 			}
 			builder.WriteString(colDef + "\n")
 		}
-		builder.WriteString(");\n\n")
+		builder.WriteString(indent + ");\n")
+		if m.cfg.IfNotExists {
+			builder.WriteString("END\n")
+		}
+		builder.WriteString("\n")
 	}
 	return builder.String(), nil
 }
 
 func (m *MSSQLDriver) buildColumnDefinition(cd columnDef) string {
-	colDef := fmt.Sprintf("[%s] %s", cd.columnName, m.formatColumnType(cd))
+	dialect := m.dialect()
+	colType := ColumnType{Name: cd.dataType, MaxLength: cd.maxLength, Precision: cd.precision, Scale: cd.scale}
+	colDef := fmt.Sprintf("%s %s", dialect.QuoteName(cd.columnName), dialect.ColumnType(colType))
+	if cd.collation != "" {
+		colDef += dialect.Collate(cd.collation)
+	}
 	if !cd.isNullable {
 		colDef += " NOT NULL"
 	}
 	if cd.isIdentity {
-		colDef += " IDENTITY(1,1)"
+		colDef += " " + dialect.IdentityClause()
 	}
 	return colDef
 }
 
-func (m *MSSQLDriver) analyzeDependencies(db *sql.DB) (DependencyTree, error) {
-	query := mssqlqQeryAnalyzeDependencies
+func (m *MSSQLDriver) analyzeDependencies(ctx context.Context, db Querier) (DependencyTree, error) {
+	query := mssqlQueryAnalyzeDependencies
 
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, apperrors.New(apperrors.ErrDBQuery, "error fetching database dependencies", err)
 	}
@@ -619,11 +1469,19 @@ func (m *MSSQLDriver) analyzeDependencies(db *sql.DB) (DependencyTree, error) {
 		childName := NewTableName(childSchema, child)
 		parentName := NewTableName(parentSchema, parent)
 
+		// Filtered-out tables are dropped here already, so they never reach the dependency analysis.
+		// References from a kept table to a filtered-out one are kept, for filterTables to report.
 		if !childName.IsEmpty() {
+			if !m.cfg.includesTable(childName) {
+				continue
+			}
 			dependencies[childName] = append(dependencies[childName], parentName)
 		}
 
-		dependencies[parentName] = make([]TableName, 0)
+		// A parent may already have its own references from an earlier row; keep them.
+		if _, exists := dependencies[parentName]; !exists && m.cfg.includesTable(parentName) {
+			dependencies[parentName] = make([]TableName, 0)
+		}
 	}
 
 	if err := rows.Err(); err != nil {
@@ -633,19 +1491,28 @@ func (m *MSSQLDriver) analyzeDependencies(db *sql.DB) (DependencyTree, error) {
 	return dependencies, nil
 }
 
+// sortTablesByDependencies orders the tables so that every table comes after the tables it references.
+// A foreign key from a table to itself does not hold the table back: it is created first and the
+// self-reference is added with the other constraints, once the data is loaded.
 func sortTablesByDependencies(deps DependencyTree) ([]TableName, error) {
-	tableDegree := make(map[TableName]int) // number of dependent tables
+	tableDegree := make(map[TableName]int) // number of distinct referenced tables, self excluded
 
 	for table, parents := range deps {
-		tableDegree[table] = len(parents)
+		for i, parent := range parents {
+			if parent != table && !slices.Contains(parents[:i], parent) {
+				tableDegree[table]++
+			}
+		}
 	}
 
+	// Ready tables are sorted by name so the resulting order is stable between runs.
 	var queue []TableName
-	for table, deg := range tableDegree {
-		if deg == 0 {
+	for table := range deps {
+		if tableDegree[table] == 0 {
 			queue = append(queue, table)
 		}
 	}
+	slices.Sort(queue)
 
 	var sorted []TableName
 	totalLenght := len(deps)
@@ -656,58 +1523,84 @@ func sortTablesByDependencies(deps DependencyTree) ([]TableName, error) {
 
 		delete(deps, table)
 
+		var ready []TableName
 		for child, parents := range deps {
-			if table.String() == NewTableName("", "ExceptionLogs").String() {
-				// fmt.Printf("Queue(%d) Child: %s | Parents: %v\n", len(queue), child, parents)
-			}
-
 			if slices.Contains(parents, table) {
-
 				tableDegree[child]--
 				if tableDegree[child] == 0 {
-					queue = append(queue, child)
+					ready = append(ready, child)
 				}
 			}
 		}
+		slices.Sort(ready)
+		queue = append(queue, ready...)
 	}
 
 	// Check if we processed all tables.
 	if len(sorted) != totalLenght {
-		return nil, apperrors.New(apperrors.ErrMigrateProcess, "cyclic dependency or incomplete dependency graph detected", nil)
+		return nil, apperrors.New(apperrors.ErrMigrateProcess, describeUnsortable(deps), nil)
 	}
 
 	return sorted, nil
 }
 
-func validateSkipList(deps DependencyTree, skipList []string) error {
-	for table, parents := range deps {
-		for _, parent := range parents {
-			if slices.Contains(skipList, parent.String()) {
-				msg := fmt.Sprintf("cannot skip table %s because it is referenced by table %s", parent, table)
-				return apperrors.New(apperrors.ErrMigrateProcess, msg, nil)
+// describeUnsortable explains why the tables left over by the topological sort could not be ordered.
+func describeUnsortable(remaining DependencyTree) string {
+	tables := remaining.tables()
+	for _, table := range tables {
+		if cycle := findCycle(remaining, table); cycle != nil {
+			names := make([]string, len(cycle))
+			for i, name := range cycle {
+				names[i] = name.String()
 			}
+			return fmt.Sprintf("cyclic dependency detected: %s", strings.Join(names, " -> "))
 		}
 	}
-	return nil
+	return fmt.Sprintf("incomplete dependency graph detected, unsorted tables: %v", tables)
 }
 
-func (m *MSSQLDriver) formatColumnType(cd columnDef) string {
-	dt := strings.ToLower(cd.dataType)
-	// Example handling for character types; you can extend this logic.
-	switch dt {
-	case "char", "varchar", "nchar", "nvarchar":
-		if cd.maxLength > 0 {
-			// For 'nchar' and 'nvarchar', max_length is in bytes (2 bytes per character), so adjust if needed.
-			return fmt.Sprintf("%s(%d)", cd.dataType, cd.maxLength)
-		}
-		return cd.dataType + "(max)"
-	default:
-		// For numeric or other types, you might want to include precision and scale.
-		if dt == "decimal" || dt == "numeric" {
-			return fmt.Sprintf("%s(%d,%d)", cd.dataType, cd.precision, cd.scale)
+// findCycle follows the references from start through the remaining tables and returns
+// the first cycle found, starting and ending with the same table, or nil if there is none.
+// Self-references are ignored, as they never block the sort.
+func findCycle(remaining DependencyTree, start TableName) []TableName {
+	var path []TableName
+	visited := make(map[TableName]bool)
+	var visit func(table TableName) []TableName
+	visit = func(table TableName) []TableName {
+		if i := slices.Index(path, table); i >= 0 {
+			return append(slices.Clone(path[i:]), table)
 		}
-		return cd.dataType
+		if visited[table] {
+			return nil
+		}
+		visited[table] = true
+		path = append(path, table)
+
+		parents := slices.Clone(remaining[table])
+		slices.Sort(parents)
+		for _, parent := range parents {
+			if _, exists := remaining[parent]; !exists || parent == table {
+				continue
+			}
+			if cycle := visit(parent); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		return nil
 	}
+	return visit(start)
 }
 
-// fmt.Print("\033[1A\033[K") // moves up and then deletes the line
+// dependencyClosure returns the table and every table it references, directly or transitively.
+func dependencyClosure(deps DependencyTree, table TableName) []TableName {
+	closure := []TableName{table}
+	for i := 0; i < len(closure); i++ {
+		for _, parent := range deps[closure[i]] {
+			if !slices.Contains(closure, parent) {
+				closure = append(closure, parent)
+			}
+		}
+	}
+	return closure
+}
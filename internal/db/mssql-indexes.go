@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+// indexInfo is an index backing no constraint, with its key columns in key order.
+type indexInfo struct {
+	schema   string
+	table    string
+	name     string
+	unique   bool
+	kind     string // "CLUSTERED" or "NONCLUSTERED", from sys.indexes.type_desc.
+	filter   string // The WHERE predicate of a filtered index, as SQL Server stores it.
+	columns  []indexColumn
+	included []string // Non-key columns of the INCLUDE clause.
+
+	fillFactor   int // 0 for the server default.
+	ignoreDupKey bool
+}
+
+// indexColumn is a key column of an index.
+type indexColumn struct {
+	name       string
+	descending bool
+}
+
+// createIndexStatement returns the CREATE INDEX statement of an index. The predicate of a
+// filtered index is T-SQL, so for another dialect the index is left out with a comment.
+// CLUSTERED and the WITH options are SQL Server storage settings, which only the mssql
+// dialect keeps.
+func (m *MSSQLDriver) createIndexStatement(idx indexInfo) string {
+	quote := m.dialect().QuoteName
+	_, native := m.dialect().(mssqlDialect)
+	if idx.filter != "" && !native {
+		return fmt.Sprintf("-- Filtered index %s on %s left out: its predicate is T-SQL.\n", quote(idx.name), quote(idx.schema, idx.table))
+	}
+
+	keys := make([]string, len(idx.columns))
+	for i, col := range idx.columns {
+		keys[i] = quote(col.name)
+		if col.descending {
+			keys[i] += " DESC"
+		}
+	}
+
+	var stmt strings.Builder
+	stmt.WriteString("CREATE ")
+	if idx.unique {
+		stmt.WriteString("UNIQUE ")
+	}
+	// The kind is always written, as a missing one means NONCLUSTERED and would turn a
+	// clustered index into a heap with a separate index.
+	if native {
+		stmt.WriteString(idx.kind + " ")
+	}
+	fmt.Fprintf(&stmt, "INDEX %s ON %s (%s)", quote(idx.name), quote(idx.schema, idx.table), strings.Join(keys, ", "))
+	if len(idx.included) > 0 {
+		included := make([]string, len(idx.included))
+		for i, col := range idx.included {
+			included[i] = quote(col)
+		}
+		fmt.Fprintf(&stmt, " INCLUDE (%s)", strings.Join(included, ", "))
+	}
+	if idx.filter != "" {
+		stmt.WriteString(" WHERE " + idx.filter)
+	}
+	if native {
+		var options []string
+		if idx.fillFactor > 0 {
+			options = append(options, fmt.Sprintf("FILLFACTOR = %d", idx.fillFactor))
+		}
+		if idx.ignoreDupKey {
+			options = append(options, "IGNORE_DUP_KEY = ON")
+		}
+		if len(options) > 0 {
+			fmt.Fprintf(&stmt, " WITH (%s)", strings.Join(options, ", "))
+		}
+	}
+	stmt.WriteString(";\n")
+	return stmt.String()
+}
+
+// getIndexes returns the indexes of the tables accepted by include that back no primary key or
+// unique constraint, ordered by schema, table and index name.
+func (m *MSSQLDriver) getIndexes(ctx context.Context, db Querier, include func(TableName) bool) ([]indexInfo, error) {
+	var rows *sql.Rows
+	err := withRetry(ctx, m.cfg.Retry, func() error {
+		var err error
+		rows, err = db.QueryContext(ctx, mssqlQueryIndexes)
+		return err
+	})
+	if err != nil {
+		return nil, apperrors.New(apperrors.ErrDBQuery, "error fetching indexes", err)
+	}
+	defer rows.Close()
+
+	// Rows come ordered by index, key columns first, so the columns of an index are consecutive.
+	var indexes []indexInfo
+	for rows.Next() {
+		var schema, table, name, kind, column string
+		var fillFactor int
+		var unique, ignoreDupKey, included, descending bool
+		var filter sql.NullString // NULL unless the index is filtered.
+		if err := rows.Scan(&schema, &table, &name, &unique, &kind, &fillFactor, &ignoreDupKey, &filter, &column, &included, &descending); err != nil {
+			return nil, apperrors.New(apperrors.ErrDBQuery, "error scanning index row", err)
+		}
+		if include != nil && !include(NewTableName(schema, table)) {
+			continue
+		}
+		n := len(indexes)
+		if n == 0 || indexes[n-1].schema != schema || indexes[n-1].table != table || indexes[n-1].name != name {
+			indexes = append(indexes, indexInfo{
+				schema:       schema,
+				table:        table,
+				name:         name,
+				unique:       unique,
+				kind:         kind,
+				filter:       filter.String,
+				fillFactor:   fillFactor,
+				ignoreDupKey: ignoreDupKey,
+			})
+			n++
+		}
+		if included {
+			indexes[n-1].included = append(indexes[n-1].included, column)
+		} else {
+			indexes[n-1].columns = append(indexes[n-1].columns, indexColumn{name: column, descending: descending})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperrors.New(apperrors.ErrDBQuery, "error iterating index rows", err)
+	}
+	return indexes, nil
+}
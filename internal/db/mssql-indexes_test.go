@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+)
+
+// indexRow returns a row of mssqlQueryIndexes for an index of dbo.Orders.
+func indexRow(index string, unique bool, kind string, fillFactor int, ignoreDupKey bool, filter any, column string, included, descending bool) []driver.Value {
+	return []driver.Value{"dbo", "Orders", index, unique, kind, fillFactor, ignoreDupKey, filter, column, included, descending}
+}
+
+// fakeIndexes returns the indexes getIndexes reads from the given rows.
+func fakeIndexes(t *testing.T, rows ...[]driver.Value) []indexInfo {
+	t.Helper()
+	db, mock := newMockDB(t)
+	mock.ExpectQuery(mssqlQueryIndexes).WillReturnRows(fakeRows(11, rows))
+	indexes, err := NewMSSQLDriver(Config{}).getIndexes(context.Background(), db, nil)
+	if err != nil {
+		t.Fatalf("getIndexes: %v", err)
+	}
+	expectationsMet(t, mock)
+	return indexes
+}
+
+func TestFilteredCoveringIndex(t *testing.T) {
+	// Key columns come first, then the included ones.
+	indexes := fakeIndexes(t,
+		indexRow("IX_Orders_Open", false, "NONCLUSTERED", 0, false, "([IsDeleted]=(0))", "CustomerId", false, false),
+		indexRow("IX_Orders_Open", false, "NONCLUSTERED", 0, false, "([IsDeleted]=(0))", "OrderDate", false, false),
+		indexRow("IX_Orders_Open", false, "NONCLUSTERED", 0, false, "([IsDeleted]=(0))", "Total", true, false),
+		indexRow("IX_Orders_Open", false, "NONCLUSTERED", 0, false, "([IsDeleted]=(0))", "Status", true, false),
+	)
+	if len(indexes) != 1 {
+		t.Fatalf("got %d indexes, want 1", len(indexes))
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{
+			name: "mssql",
+			want: "CREATE NONCLUSTERED INDEX [IX_Orders_Open] ON [dbo].[Orders] ([CustomerId], [OrderDate]) INCLUDE ([Total], [Status]) WHERE ([IsDeleted]=(0));\n",
+		},
+		{
+			// The predicate is T-SQL, so the index is not written for another dialect.
+			name: "postgres",
+			cfg:  Config{TargetDialect: postgresDialect{}},
+			want: "-- Filtered index \"IX_Orders_Open\" on \"dbo\".\"Orders\" left out: its predicate is T-SQL.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewMSSQLDriver(tt.cfg).createIndexStatement(indexes[0]); got != tt.want {
+				t.Errorf("createIndexStatement() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusteredDescendingIndex(t *testing.T) {
+	indexes := fakeIndexes(t,
+		indexRow("CIX_Orders_Date", true, "CLUSTERED", 80, true, nil, "OrderDate", false, true),
+		indexRow("CIX_Orders_Date", true, "CLUSTERED", 80, true, nil, "Id", false, false),
+		indexRow("IX_Orders_Total", false, "NONCLUSTERED", 0, false, nil, "Total", false, true),
+	)
+	if len(indexes) != 2 {
+		t.Fatalf("got %d indexes, want 2", len(indexes))
+	}
+
+	tests := []struct {
+		name  string
+		cfg   Config
+		index indexInfo
+		want  string
+	}{
+		{
+			name:  "clustered with options",
+			index: indexes[0],
+			want:  "CREATE UNIQUE CLUSTERED INDEX [CIX_Orders_Date] ON [dbo].[Orders] ([OrderDate] DESC, [Id]) WITH (FILLFACTOR = 80, IGNORE_DUP_KEY = ON);\n",
+		},
+		{
+			// A fill factor of 0 is the server default, and writes no WITH clause.
+			name:  "nonclustered without options",
+			index: indexes[1],
+			want:  "CREATE NONCLUSTERED INDEX [IX_Orders_Total] ON [dbo].[Orders] ([Total] DESC);\n",
+		},
+		{
+			// The kind and options are SQL Server storage settings; the key directions are kept.
+			name:  "postgres",
+			cfg:   Config{TargetDialect: postgresDialect{}},
+			index: indexes[0],
+			want:  "CREATE UNIQUE INDEX \"CIX_Orders_Date\" ON \"dbo\".\"Orders\" (\"OrderDate\" DESC, \"Id\");\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewMSSQLDriver(tt.cfg).createIndexStatement(tt.index); got != tt.want {
+				t.Errorf("createIndexStatement() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
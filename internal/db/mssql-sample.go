@@ -0,0 +1,456 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+// DefaultSampleMaxRows caps the rows of a sampled dump when Config.SampleMaxRows is not set.
+const DefaultSampleMaxRows = 10000
+
+// sampleChunkSize is the number of key values looked up per query while following foreign keys.
+const sampleChunkSize = 500
+
+// planSample picks the rows of a sampled data dump and returns, for every table, the predicates
+// selecting them, each matching at most sampleChunkSize key values so that no query grows too
+// long. No row matches more than one of a table's predicates; a table without sampled rows has none.
+//
+// The sample starts from the tables no other table references, such as order lines or audit
+// rows, and from one table of every cycle no other table references: Config.Sample of their
+// rows are picked at random, by primary key. The foreign keys of the picked rows are then
+// followed, breadth first, and the rows they reference are added, until no new row turns up. Self-references and cycles are followed the same way, as a row is
+// only ever fetched once. Rows are matched on the referenced columns, which are a primary key
+// or unique constraint, so each value adds at most one row.
+//
+// Tables without a primary key cannot have their rows picked, so they only get the rows other
+// sampled rows reference. Random rows are picked among those matching the table's Config.Where
+// predicate, if any. A sample growing beyond SampleMaxRows is an error rather than being
+// cut short, since a cut sample would no longer be consistent.
+func (m *MSSQLDriver) planSample(ctx context.Context, db Querier, tables []TableName, mappings TableMapping, primaryKeys map[TableName][]string) (map[TableName][]string, error) {
+	sampled := make(map[TableName]bool, len(tables))
+	for _, table := range tables {
+		sampled[table] = true
+	}
+	foreignKeys, err := m.getForeignKeys(ctx, db, func(t TableName) bool { return sampled[t] })
+	if err != nil {
+		return nil, err
+	}
+	plan := &samplePlan{
+		m:        m,
+		db:       db,
+		mappings: mappings,
+		keys:     make(map[TableName][]*sampleKey),
+		outgoing: make(map[TableName][]foreignKeyInfo),
+		maxRows:  m.cfg.SampleMaxRows,
+	}
+	if plan.maxRows <= 0 {
+		plan.maxRows = DefaultSampleMaxRows
+	}
+	for _, fk := range foreignKeys {
+		child, parent := NewTableName(fk.childSchema, fk.childTable), NewTableName(fk.parentSchema, fk.parentTable)
+		if sampled[parent] {
+			plan.outgoing[child] = append(plan.outgoing[child], fk)
+		}
+	}
+
+	roots := plan.roots(tables, primaryKeys)
+	m.progress().Start("Sampling rows", len(roots))
+	defer m.progress().Done()
+	for _, table := range roots {
+		if err := plan.pickRandom(ctx, table, primaryKeys[table]); err != nil {
+			return nil, err
+		}
+		m.progress().Increment(1)
+	}
+	// The foreign keys are followed until no table has values left to look up.
+	for found := true; found; {
+		found = false
+		for _, table := range tables {
+			for _, key := range plan.keys[table] {
+				for len(key.pending) > 0 {
+					found = true
+					chunk := key.pending[:min(len(key.pending), sampleChunkSize)]
+					key.pending = key.pending[len(chunk):]
+					if err := plan.follow(ctx, table, key, chunk); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+
+	predicates := make(map[TableName][]string, len(tables))
+	for _, table := range tables {
+		if err := plan.rekey(ctx, table, primaryKeys[table]); err != nil {
+			return nil, err
+		}
+		chunks := []string{}
+		for _, key := range plan.keys[table] {
+			for values := key.order; len(values) > 0; {
+				chunk := values[:min(len(values), sampleChunkSize)]
+				values = values[len(chunk):]
+				chunks = append(chunks, plan.match(table, key.columns, chunk))
+			}
+		}
+		predicates[table] = chunks
+	}
+	return predicates, nil
+}
+
+// roots returns the tables whose rows are picked at random, in table order. Those are the tables
+// no other table references, and for every cycle no table outside it references, such as two
+// tables referencing each other, its first table with a primary key: the rows of such a cycle
+// could otherwise never be reached. A root without a primary key cannot be picked from, so the
+// tables it references get no rows through it either.
+func (p *samplePlan) roots(tables []TableName, primaryKeys map[TableName][]string) []TableName {
+	reaches := make(map[TableName]map[TableName]bool, len(tables))
+	reach := func(table TableName) map[TableName]bool {
+		if reached, ok := reaches[table]; ok {
+			return reached
+		}
+		reached := map[TableName]bool{table: true}
+		for queue := []TableName{table}; len(queue) > 0; queue = queue[1:] {
+			for _, fk := range p.outgoing[queue[0]] {
+				if parent := NewTableName(fk.parentSchema, fk.parentTable); !reached[parent] {
+					reached[parent] = true
+					queue = append(queue, parent)
+				}
+			}
+		}
+		reaches[table] = reached
+		return reached
+	}
+
+	var roots []TableName
+	reached := make(map[TableName]bool)
+	for _, table := range tables {
+		if reached[table] {
+			continue
+		}
+		// A table referenced from outside its cycle by a table not reached yet gets its rows through that one.
+		if slices.ContainsFunc(tables, func(other TableName) bool {
+			return !reached[other] && !reach(table)[other] && reach(other)[table]
+		}) {
+			continue
+		}
+		// The tables of the cycle are those the table reaches and that reach it back.
+		i := slices.IndexFunc(tables, func(t TableName) bool {
+			return reach(table)[t] && reach(t)[table] && len(primaryKeys[t]) > 0
+		})
+		if i < 0 {
+			continue
+		}
+		roots = append(roots, tables[i])
+		for t := range reach(tables[i]) {
+			reached[t] = true
+		}
+	}
+	slices.SortStableFunc(roots, func(a, b TableName) int {
+		return slices.Index(tables, a) - slices.Index(tables, b)
+	})
+	return roots
+}
+
+// samplePlan holds the rows picked by planSample so far.
+type samplePlan struct {
+	m        *MSSQLDriver
+	db       Querier
+	mappings TableMapping
+	keys     map[TableName][]*sampleKey // The picked rows of every table, by the columns matching them.
+	outgoing map[TableName][]foreignKeyInfo
+	rows     int
+	maxRows  int
+}
+
+// sampleKey holds the values of a set of key columns that select the picked rows of a table.
+type sampleKey struct {
+	columns []string
+	values  map[string]bool // Every value added, its parts joined with NUL.
+	order   [][]string      // The values in the order they were added, so dumps are repeatable.
+	pending [][]string      // The values whose rows were not fetched yet.
+}
+
+// add records a key value of a table's row, which must be fetched unless fetched is set.
+// Values with a NULL part reference nothing and are ignored.
+func (p *samplePlan) add(table TableName, columns []string, value []sql.NullString, fetched bool) error {
+	parts := make([]string, len(value))
+	for i, v := range value {
+		if !v.Valid {
+			return nil
+		}
+		parts[i] = v.String
+	}
+	var key *sampleKey
+	for _, k := range p.keys[table] {
+		if slicesEqualFold(k.columns, columns) {
+			key = k
+			break
+		}
+	}
+	if key == nil {
+		key = &sampleKey{columns: columns, values: make(map[string]bool)}
+		p.keys[table] = append(p.keys[table], key)
+	}
+	if !key.insert(parts) {
+		return nil
+	}
+	p.rows++
+	if p.rows > p.maxRows {
+		msg := fmt.Sprintf("the sample grows beyond %d rows; lower --sample or raise --sample-max-rows", p.maxRows)
+		return apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+	}
+	if !fetched {
+		key.pending = append(key.pending, parts)
+	}
+	return nil
+}
+
+// insert records a value of the key, reporting whether it is new.
+func (k *sampleKey) insert(parts []string) bool {
+	encoded := strings.Join(parts, "\x00")
+	if k.values[encoded] {
+		return false
+	}
+	k.values[encoded] = true
+	k.order = append(k.order, parts)
+	return true
+}
+
+// rekey moves the rows of a table picked through several keys, e.g. its primary key and a unique
+// constraint another table references, under a single one: the primary key if the table has one,
+// else the first key. The predicates of different keys could otherwise select the same row twice.
+// A row whose value of that key has a NULL part cannot be matched by it and stays under its own key.
+func (p *samplePlan) rekey(ctx context.Context, table TableName, primaryKey []string) error {
+	keys := p.keys[table]
+	if len(keys) < 2 {
+		return nil
+	}
+	target := keys[0]
+	if len(primaryKey) > 0 {
+		target = nil
+		for _, key := range keys {
+			if slicesEqualFold(key.columns, primaryKey) {
+				target = key
+			}
+		}
+		if target == nil {
+			target = &sampleKey{columns: primaryKey, values: make(map[string]bool)}
+		}
+	}
+
+	kept := []*sampleKey{target}
+	for _, key := range keys {
+		if key == target {
+			continue
+		}
+		rest := &sampleKey{columns: key.columns, values: make(map[string]bool)}
+		for values := key.order; len(values) > 0; {
+			chunk := values[:min(len(values), sampleChunkSize)]
+			values = values[len(chunk):]
+			var exprs []string
+			for _, col := range append(slices.Clone(target.columns), key.columns...) {
+				exprs = append(exprs, keyText(p.column(table, col)))
+			}
+			query := fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(exprs, ", "), table, p.match(table, key.columns, chunk))
+			err := p.query(ctx, table, query, func(row []sql.NullString) {
+				parts := make([]string, len(row))
+				for i, v := range row {
+					parts[i] = v.String
+				}
+				n := len(target.columns)
+				if slices.ContainsFunc(row[:n], func(v sql.NullString) bool { return !v.Valid }) {
+					rest.insert(parts[n:])
+					return
+				}
+				target.insert(parts[:n])
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if len(rest.order) > 0 {
+			kept = append(kept, rest)
+		}
+	}
+	p.keys[table] = kept
+	return nil
+}
+
+// pickRandom picks Config.Sample random rows of a table, identified by its primary key.
+func (p *samplePlan) pickRandom(ctx context.Context, table TableName, primaryKey []string) error {
+	where := ""
+	if predicate, ok := p.m.cfg.Where[table]; ok {
+		where = " WHERE " + predicate
+	}
+	query := fmt.Sprintf("SELECT TOP (%d) %s FROM %s%s ORDER BY NEWID()", p.m.cfg.Sample, p.selectList(table, primaryKey), table, where)
+	return p.fetch(ctx, table, query, primaryKey)
+}
+
+// follow fetches the rows of a table matching the given values of a key, and adds the rows
+// their foreign keys reference.
+func (p *samplePlan) follow(ctx context.Context, table TableName, key *sampleKey, values [][]string) error {
+	if len(p.outgoing[table]) == 0 {
+		// The rows reference nothing, so there is nothing to fetch.
+		return nil
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s", p.selectList(table, nil), table, p.match(table, key.columns, values))
+	return p.fetch(ctx, table, query, nil)
+}
+
+// selectList returns the columns read from the sampled rows of a table: the given key columns,
+// then the columns of every foreign key being followed, all as text.
+func (p *samplePlan) selectList(table TableName, key []string) string {
+	var exprs []string
+	for _, col := range key {
+		exprs = append(exprs, keyText(p.column(table, col)))
+	}
+	for _, fk := range p.outgoing[table] {
+		for _, col := range fk.childColumns {
+			exprs = append(exprs, keyText(p.column(table, col)))
+		}
+	}
+	return strings.Join(exprs, ", ")
+}
+
+// fetch runs a query built by selectList and records its rows: their key, when given, as
+// already fetched, and the values of their foreign keys as rows to fetch.
+func (p *samplePlan) fetch(ctx context.Context, table TableName, query string, key []string) error {
+	var addErr error
+	err := p.query(ctx, table, query, func(values []sql.NullString) {
+		if addErr != nil {
+			return
+		}
+		if len(key) > 0 {
+			if addErr = p.add(table, key, values[:len(key)], true); addErr != nil {
+				return
+			}
+		}
+		offset := len(key)
+		for _, fk := range p.outgoing[table] {
+			n := len(fk.childColumns)
+			if addErr = p.add(NewTableName(fk.parentSchema, fk.parentTable), fk.parentColumns, values[offset:offset+n], false); addErr != nil {
+				return
+			}
+			offset += n
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return addErr
+}
+
+// query runs a query reading key columns as text and calls onRow with the values of every row.
+func (p *samplePlan) query(ctx context.Context, table TableName, query string, onRow func(values []sql.NullString)) error {
+	var rows *sql.Rows
+	err := withRetry(ctx, p.m.cfg.Retry, func() error {
+		var err error
+		rows, err = p.db.QueryContext(ctx, query)
+		return err
+	})
+	if err != nil {
+		return apperrors.New(apperrors.ErrDataDump, "failed to sample rows", err).WithTable(table.String())
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return apperrors.New(apperrors.ErrDataDump, "failed to sample rows", err).WithTable(table.String())
+	}
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		ptrs := make([]any, len(values))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return apperrors.New(apperrors.ErrDataDump, "failed to scan sampled row", err).WithTable(table.String())
+		}
+		onRow(values)
+	}
+	if err := rows.Err(); err != nil {
+		return apperrors.New(apperrors.ErrDataDump, "error iterating sampled rows", err).WithTable(table.String())
+	}
+	return nil
+}
+
+// match returns the predicate selecting the rows of a table whose key columns hold one of the
+// given values.
+func (p *samplePlan) match(table TableName, columns []string, values [][]string) string {
+	if len(columns) == 1 {
+		col := p.column(table, columns[0])
+		literals := make([]string, len(values))
+		for i, value := range values {
+			literals[i] = keyLiteral(col, value[0])
+		}
+		return fmt.Sprintf("%s IN (%s)", FormatObjectName(col.columnName), strings.Join(literals, ", "))
+	}
+	conditions := make([]string, len(values))
+	for i, value := range values {
+		parts := make([]string, len(columns))
+		for j, name := range columns {
+			col := p.column(table, name)
+			parts[j] = fmt.Sprintf("%s = %s", FormatObjectName(col.columnName), keyLiteral(col, value[j]))
+		}
+		conditions[i] = "(" + strings.Join(parts, " AND ") + ")"
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// column returns the definition of a table's column, or one of an unknown type if the
+// mappings lack it.
+func (p *samplePlan) column(table TableName, name string) columnDef {
+	for _, col := range p.mappings[table] {
+		if strings.EqualFold(col.columnName, name) {
+			return col
+		}
+	}
+	return columnDef{columnName: name}
+}
+
+// keyText returns the expression reading a key column as text that keyLiteral turns back into
+// a value of the column: datetime keeps its milliseconds, float its every digit and binary
+// its bytes as 0x hex.
+func keyText(col columnDef) string {
+	name := FormatObjectName(col.columnName)
+	switch strings.ToLower(col.dataType) {
+	case "datetime", "smalldatetime":
+		return fmt.Sprintf("CONVERT(nvarchar(30), %s, 121)", name)
+	case "float", "real":
+		return fmt.Sprintf("CONVERT(nvarchar(30), %s, 3)", name)
+	case "binary", "varbinary":
+		return fmt.Sprintf("CONVERT(nvarchar(max), %s, 1)", name)
+	default:
+		return fmt.Sprintf("CONVERT(nvarchar(max), %s)", name)
+	}
+}
+
+// keyLiteral returns the literal of a value read with keyText. Values other than binary ones
+// are string literals, which the server converts to the type of the column they are
+// compared with.
+func keyLiteral(col columnDef, text string) string {
+	switch strings.ToLower(col.dataType) {
+	case "binary", "varbinary":
+		return text
+	default:
+		return unicodeLiteral(text)
+	}
+}
+
+// slicesEqualFold reports whether both lists hold the same names, ignoring case.
+func slicesEqualFold(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,246 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+// sampleQuery is a query planSample is expected to run, with the rows it returns.
+type sampleQuery struct {
+	query string
+	rows  [][]driver.Value
+}
+
+// sampleTables describes the sampled tables: their names, in table order, and their int
+// columns, the first of which is the primary key unless noKey lists the table.
+type sampleTables struct {
+	columns map[string][]string
+	order   []string
+	noKey   []string
+}
+
+// planFakeSample runs planSample over tables with the given foreign keys (child table, child
+// column, parent table, parent column, all in dbo), expecting the given queries in order.
+func planFakeSample(t *testing.T, cfg Config, tables sampleTables, foreignKeys [][4]string, queries []sampleQuery) (map[TableName][]string, error) {
+	t.Helper()
+	db, mock := newMockDB(t)
+	var fks [][]driver.Value
+	for _, fk := range foreignKeys {
+		name := "FK_" + fk[0] + "_" + fk[2]
+		fks = append(fks, []driver.Value{"dbo", fk[0], name, "dbo", fk[2], fk[1], fk[3], "NO ACTION", "NO ACTION", 1})
+	}
+	mock.ExpectQuery(mssqlQueryForeignKeys).WillReturnRows(fakeRows(10, fks))
+	for _, q := range queries {
+		mock.ExpectQuery(q.query).WillReturnRows(fakeRows(len(q.rows[0]), q.rows))
+	}
+
+	var names []TableName
+	mappings := make(TableMapping)
+	primaryKeys := make(map[TableName][]string)
+	for _, name := range tables.order {
+		table := NewTableName("dbo", name)
+		names = append(names, table)
+		var columns []string
+		for _, col := range tables.columns[name] {
+			columns = append(columns, col+" int")
+		}
+		mappings[table] = fakeColumns(table, false, columns...)
+		if !slices.Contains(tables.noKey, name) {
+			primaryKeys[table] = tables.columns[name][:1]
+		}
+	}
+	plan, err := NewMSSQLDriver(cfg).planSample(context.Background(), db, names, mappings, primaryKeys)
+	if err == nil {
+		expectationsMet(t, mock)
+	}
+	return plan, err
+}
+
+// text converts a value to the text a key column is read as.
+func text(values ...string) []driver.Value {
+	row := make([]driver.Value, len(values))
+	for i, v := range values {
+		row[i] = v
+	}
+	return row
+}
+
+func TestPlanSample(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         Config
+		tables      sampleTables
+		foreignKeys [][4]string
+		queries     []sampleQuery
+		want        map[string][]string
+	}{
+		{
+			name: "root and the parents it references",
+			cfg:  Config{Sample: 2},
+			tables: sampleTables{
+				columns: map[string][]string{"Lines": {"Id", "OrderId"}, "Orders": {"Id", "CustomerId"}, "Customers": {"Id"}},
+				order:   []string{"Customers", "Orders", "Lines"},
+			},
+			foreignKeys: [][4]string{{"Lines", "OrderId", "Orders", "Id"}, {"Orders", "CustomerId", "Customers", "Id"}},
+			queries: []sampleQuery{
+				{
+					"SELECT TOP (2) CONVERT(nvarchar(max), [Id]), CONVERT(nvarchar(max), [OrderId]) FROM [dbo].[Lines] ORDER BY NEWID()",
+					[][]driver.Value{text("1", "10"), text("2", "10")},
+				},
+				{
+					"SELECT CONVERT(nvarchar(max), [CustomerId]) FROM [dbo].[Orders] WHERE [Id] IN (N'10')",
+					[][]driver.Value{text("7")},
+				},
+			},
+			want: map[string][]string{
+				"Lines":     {"[Id] IN (N'1', N'2')"},
+				"Orders":    {"[Id] IN (N'10')"},
+				"Customers": {"[Id] IN (N'7')"},
+			},
+		},
+		{
+			name: "cycle no other table references",
+			cfg:  Config{Sample: 1},
+			tables: sampleTables{
+				columns: map[string][]string{"A": {"Id", "BId"}, "B": {"Id", "AId"}, "Loose": {"Id"}},
+				order:   []string{"A", "B", "Loose"},
+			},
+			foreignKeys: [][4]string{{"A", "BId", "B", "Id"}, {"B", "AId", "A", "Id"}},
+			queries: []sampleQuery{
+				{
+					"SELECT TOP (1) CONVERT(nvarchar(max), [Id]), CONVERT(nvarchar(max), [BId]) FROM [dbo].[A] ORDER BY NEWID()",
+					[][]driver.Value{text("1", "5")},
+				},
+				{"SELECT TOP (1) CONVERT(nvarchar(max), [Id]) FROM [dbo].[Loose] ORDER BY NEWID()", [][]driver.Value{text("3")}},
+				{"SELECT CONVERT(nvarchar(max), [AId]) FROM [dbo].[B] WHERE [Id] IN (N'5')", [][]driver.Value{text("2")}},
+				{"SELECT CONVERT(nvarchar(max), [BId]) FROM [dbo].[A] WHERE [Id] IN (N'2')", [][]driver.Value{text("5")}},
+			},
+			want: map[string][]string{
+				"A":     {"[Id] IN (N'1', N'2')"},
+				"B":     {"[Id] IN (N'5')"},
+				"Loose": {"[Id] IN (N'3')"},
+			},
+		},
+		{
+			name: "cycle seeded from its first table with a primary key",
+			cfg:  Config{Sample: 1},
+			tables: sampleTables{
+				columns: map[string][]string{"A": {"Id", "BId"}, "B": {"Id", "AId"}},
+				order:   []string{"A", "B"},
+				noKey:   []string{"A"},
+			},
+			foreignKeys: [][4]string{{"A", "BId", "B", "Id"}, {"B", "AId", "A", "Id"}},
+			queries: []sampleQuery{
+				{
+					"SELECT TOP (1) CONVERT(nvarchar(max), [Id]), CONVERT(nvarchar(max), [AId]) FROM [dbo].[B] ORDER BY NEWID()",
+					[][]driver.Value{text("5", "1")},
+				},
+				{"SELECT CONVERT(nvarchar(max), [BId]) FROM [dbo].[A] WHERE [Id] IN (N'1')", [][]driver.Value{text("5")}},
+			},
+			want: map[string][]string{
+				"A": {"[Id] IN (N'1')"},
+				"B": {"[Id] IN (N'5')"},
+			},
+		},
+		{
+			name: "cycle referenced by a root",
+			cfg:  Config{Sample: 1},
+			tables: sampleTables{
+				columns: map[string][]string{"A": {"Id", "BId"}, "B": {"Id", "AId"}, "Child": {"Id", "AId"}},
+				order:   []string{"A", "B", "Child"},
+			},
+			foreignKeys: [][4]string{{"A", "BId", "B", "Id"}, {"B", "AId", "A", "Id"}, {"Child", "AId", "A", "Id"}},
+			queries: []sampleQuery{
+				{
+					"SELECT TOP (1) CONVERT(nvarchar(max), [Id]), CONVERT(nvarchar(max), [AId]) FROM [dbo].[Child] ORDER BY NEWID()",
+					[][]driver.Value{text("9", "1")},
+				},
+				{"SELECT CONVERT(nvarchar(max), [BId]) FROM [dbo].[A] WHERE [Id] IN (N'1')", [][]driver.Value{text("5")}},
+				{"SELECT CONVERT(nvarchar(max), [AId]) FROM [dbo].[B] WHERE [Id] IN (N'5')", [][]driver.Value{text("1")}},
+			},
+			want: map[string][]string{
+				"A":     {"[Id] IN (N'1')"},
+				"B":     {"[Id] IN (N'5')"},
+				"Child": {"[Id] IN (N'9')"},
+			},
+		},
+		{
+			name: "row reached through the primary key and a unique key",
+			cfg:  Config{Sample: 2},
+			tables: sampleTables{
+				columns: map[string][]string{"Customers": {"Id", "Code"}, "Orders": {"Id", "CustomerId"}, "Invoices": {"Id", "CustomerCode"}},
+				order:   []string{"Customers", "Orders", "Invoices"},
+			},
+			foreignKeys: [][4]string{{"Orders", "CustomerId", "Customers", "Id"}, {"Invoices", "CustomerCode", "Customers", "Code"}},
+			queries: []sampleQuery{
+				{
+					"SELECT TOP (2) CONVERT(nvarchar(max), [Id]), CONVERT(nvarchar(max), [CustomerId]) FROM [dbo].[Orders] ORDER BY NEWID()",
+					[][]driver.Value{text("10", "7"), text("11", "7")},
+				},
+				{
+					"SELECT TOP (2) CONVERT(nvarchar(max), [Id]), CONVERT(nvarchar(max), [CustomerCode]) FROM [dbo].[Invoices] ORDER BY NEWID()",
+					[][]driver.Value{text("20", "C7"), text("21", "C8")},
+				},
+				// C7 is customer 7, which the orders already reach through the primary key.
+				{
+					"SELECT CONVERT(nvarchar(max), [Id]), CONVERT(nvarchar(max), [Code]) FROM [dbo].[Customers] WHERE [Code] IN (N'C7', N'C8')",
+					[][]driver.Value{text("7", "C7"), text("8", "C8")},
+				},
+			},
+			want: map[string][]string{
+				"Customers": {"[Id] IN (N'7', N'8')"},
+				"Orders":    {"[Id] IN (N'10', N'11')"},
+				"Invoices":  {"[Id] IN (N'20', N'21')"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := planFakeSample(t, tt.cfg, tt.tables, tt.foreignKeys, tt.queries)
+			if err != nil {
+				t.Fatalf("planSample: %v", err)
+			}
+			for _, name := range tt.tables.order {
+				if got := plan[NewTableName("dbo", name)]; !slices.Equal(got, tt.want[name]) {
+					t.Errorf("predicates of %s = %q, want %q", name, got, tt.want[name])
+				}
+			}
+		})
+	}
+}
+
+func TestPlanSampleMaxRows(t *testing.T) {
+	tables := sampleTables{
+		columns: map[string][]string{"Lines": {"Id", "OrderId"}, "Orders": {"Id"}},
+		order:   []string{"Orders", "Lines"},
+	}
+	queries := []sampleQuery{{
+		"SELECT TOP (2) CONVERT(nvarchar(max), [Id]), CONVERT(nvarchar(max), [OrderId]) FROM [dbo].[Lines] ORDER BY NEWID()",
+		[][]driver.Value{text("1", "10"), text("2", "11")},
+	}}
+	// Two lines and the two orders they reference make four rows.
+	_, err := planFakeSample(t, Config{Sample: 2, SampleMaxRows: 3}, tables, [][4]string{{"Lines", "OrderId", "Orders", "Id"}}, queries)
+	if !apperrors.HasCode(err, apperrors.ErrInvalidInput) || !strings.Contains(err.Error(), "the sample grows beyond 3 rows") {
+		t.Fatalf("error = %v, want an ErrInvalidInput saying the sample grows beyond 3 rows", err)
+	}
+}
+
+func TestPlanSampleQueryError(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectQuery(mssqlQueryForeignKeys).WillReturnRows(fakeRows(10, nil))
+	mock.ExpectQuery("SELECT TOP (1) CONVERT(nvarchar(max), [Id]) FROM [dbo].[T] ORDER BY NEWID()").
+		WillReturnError(sqlmock.ErrCancelled)
+
+	table := NewTableName("dbo", "T")
+	_, err := NewMSSQLDriver(Config{Sample: 1}).planSample(context.Background(), db, []TableName{table},
+		TableMapping{table: fakeColumns(table, false, "Id int")}, map[TableName][]string{table: {"Id"}})
+	if !apperrors.HasCode(err, apperrors.ErrDataDump) || apperrors.TableOf(err) != "[dbo].[T]" {
+		t.Fatalf("error = %v, want an ErrDataDump of [dbo].[T]", err)
+	}
+}
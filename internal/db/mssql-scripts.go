@@ -1,64 +1,57 @@
 package db
 
-import "fmt"
-
-// SQL query constants.
-const (
-	mssqlQueryTableMappings = `
-SELECT 
-    s.name AS [schema],
-    t.name AS [table],
-    c.name AS [column],
-    c.column_id AS [column_position],
-    tp.name AS [data_type],
-    c.max_length AS [max_length],
-    c.precision,
-    c.scale,
-    c.is_nullable AS [is_nullable],
-    c.is_identity AS [is_identity],
-    c.is_computed AS [is_computed]
-FROM 
-    sys.tables t
-JOIN 
-    sys.schemas s ON t.schema_id = s.schema_id
-JOIN 
-    sys.columns c ON t.object_id = c.object_id
-JOIN 
-    sys.types tp ON c.user_type_id = tp.user_type_id
-WHERE 
-    t.type = 'U'
-`
+import (
+	"fmt"
+	"strings"
+)
 
-	mssqlqQeryAnalyzeDependencies = `
-	SELECT DISTINCT
-        fk.TABLE_SCHEMA AS ChildSchema,
-        fk.TABLE_NAME AS ChildTable,
-        pk.TABLE_SCHEMA AS ParentSchema, 
-        pk.TABLE_NAME AS ParentTable 
-    FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc 
-    FULL JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS fk ON rc.CONSTRAINT_NAME = fk.CONSTRAINT_NAME 
-    FULL JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS pk ON rc.UNIQUE_CONSTRAINT_NAME = pk.CONSTRAINT_NAME
-    WHERE pk.TABLE_NAME IS NOT NULL
-    ORDER BY fk.TABLE_NAME ASC;
-	`
+// BatchSeparator ends a T-SQL batch with a bare GO line, as sqlcmd and SSMS expect.
+const BatchSeparator = "\nGO\n\n"
 
-	tableListQuery = `
-	SELECT 
-		TABLE_SCHEMA,
-		TABLE_NAME 
-	FROM 
-		INFORMATION_SCHEMA.TABLES 
-	WHERE 
-		TABLE_TYPE = 'BASE TABLE' 
-		AND TABLE_CATALOG = DB_NAME();
-	`
+// SQL queries, embedded from the scripts directory.
+var (
+	mssqlQueryTableMappings       = mustLoadScript("mssql-table-mappings.sql")
+	mssqlQueryPrimaryKeys         = mustLoadScript("mssql-primary-keys.sql")
+	mssqlQueryUniqueConstraints   = mustLoadScript("mssql-unique-constraints.sql")
+	mssqlQueryDescriptions        = mustLoadScript("mssql-descriptions.sql")
+	mssqlQueryIndexes             = mustLoadScript("mssql-indexes.sql")
+	mssqlQueryForeignKeys         = mustLoadScript("mssql-foreign-keys.sql")
+	mssqlQueryAnalyzeDependencies = mustLoadScript("mssql-dependencies.sql")
+	mssqlQueryRowEstimates        = mustLoadScript("mssql-row-estimates.sql")
+	tableListQuery                = mustLoadScript("mssql-tables.sql")
 )
 
 func GetCreateSchemaQuery(schemaName string) string {
+	quoted := strings.ReplaceAll(schemaName, "'", "''")
+	createStmt := strings.ReplaceAll("CREATE SCHEMA "+FormatObjectName(schemaName), "'", "''")
 	return fmt.Sprintf(`
 IF NOT EXISTS (SELECT * FROM sys.schemas WHERE name = '%s')
 BEGIN
-    EXEC('CREATE SCHEMA %s')
+    EXEC('%s')
 END
-`, schemaName, schemaName)
+`, quoted, createStmt)
+}
+
+// GetDropTablesQuery drops the given tables, listed in dependency order, if they exist.
+// Foreign keys referencing them are dropped first, including those of tables outside the list,
+// and the tables are then dropped in reverse order so referencing tables go before referenced ones.
+func GetDropTablesQuery(sortedTables []TableName) string {
+	var ids []string
+	for _, table := range sortedTables {
+		ids = append(ids, fmt.Sprintf("OBJECT_ID(N'%s')", strings.ReplaceAll(table.String(), "'", "''")))
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf(`
+DECLARE @dropForeignKeys NVARCHAR(MAX) = N'';
+SELECT @dropForeignKeys += N'ALTER TABLE ' + QUOTENAME(OBJECT_SCHEMA_NAME(fk.parent_object_id)) + N'.'
+    + QUOTENAME(OBJECT_NAME(fk.parent_object_id)) + N' DROP CONSTRAINT ' + QUOTENAME(fk.name) + N';'
+FROM sys.foreign_keys fk
+WHERE fk.referenced_object_id IN (%s);
+EXEC sp_executesql @dropForeignKeys;
+`, strings.Join(ids, ", ")))
+	for i := len(sortedTables) - 1; i >= 0; i-- {
+		builder.WriteString(fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", sortedTables[i]))
+	}
+	return builder.String()
 }
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+var (
+	// stringLiteral matches a T-SQL string literal, including escaped quotes.
+	stringLiteral = regexp.MustCompile(`N?'(?:[^']|'')*'`)
+
+	// forbiddenPredicate matches constructs that would let a predicate escape its own table.
+	forbiddenPredicate = regexp.MustCompile(`(?i);|--|/\*|\b(SELECT|FROM|JOIN|UNION|EXEC|EXECUTE|INSERT|UPDATE|DELETE|DROP|ALTER|CREATE|TRUNCATE|MERGE)\b`)
+
+	// identifier matches a bracketed or regular identifier.
+	identifier = regexp.MustCompile(`\[(?:[^\]]|\]\])+\]|[A-Za-z_]\w*`)
+
+	// qualifiedName matches a qualified column reference such as Orders.Id or [dbo].[Orders].[Id].
+	qualifiedName = regexp.MustCompile(`((?:(?:\[(?:[^\]]|\]\])+\]|[A-Za-z_]\w*)\s*\.\s*)+)(?:\[(?:[^\]]|\]\])+\]|[A-Za-z_]\w*)`)
+)
+
+// ParseWhere reads row filters keyed by table name, as a user writes them (e.g. "dbo.Orders"),
+// into the Where of a Config. Every predicate is checked with ValidatePredicate, and two names
+// of the same table are an error.
+func ParseWhere(predicates map[string]string) (map[TableName]string, error) {
+	if len(predicates) == 0 {
+		return nil, nil
+	}
+	where := make(map[TableName]string, len(predicates))
+	for name, predicate := range predicates {
+		table := ParseTableName(name)
+		if _, exists := where[table]; exists {
+			msg := fmt.Sprintf("row filter given more than once for table %s", table)
+			return nil, apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+		}
+		if err := ValidatePredicate(table, predicate); err != nil {
+			return nil, err
+		}
+		where[table] = strings.TrimSpace(predicate)
+	}
+	return where, nil
+}
+
+// ValidatePredicate checks that a --where predicate only refers to the given table.
+// This is a safety net against mistakes, not a full SQL parser: predicates are still
+// inserted verbatim into the query, so they must come from a trusted source.
+func ValidatePredicate(table TableName, predicate string) error {
+	if strings.TrimSpace(predicate) == "" {
+		msg := fmt.Sprintf("empty predicate for table %s", table)
+		return apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+	}
+
+	// String literals may legitimately contain anything, so blank them out first.
+	code := stringLiteral.ReplaceAllString(predicate, "0")
+	if strings.Contains(code, "'") {
+		msg := fmt.Sprintf("unterminated string literal in predicate for table %s", table)
+		return apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+	}
+	if match := forbiddenPredicate.FindString(code); match != "" {
+		msg := fmt.Sprintf("predicate for table %s may not contain %q", table, match)
+		return apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+	}
+
+	schema, name := table.GetParts()
+	for _, match := range qualifiedName.FindAllStringSubmatch(code, -1) {
+		// The qualifier is "table." or "schema.table."; it must name the dumped table.
+		var parts []string
+		for _, part := range identifier.FindAllString(match[1], -1) {
+			if strings.HasPrefix(part, "[") {
+				part = strings.ReplaceAll(part[1:len(part)-1], "]]", "]")
+			}
+			parts = append(parts, part)
+		}
+		sameTable := strings.EqualFold(parts[len(parts)-1], name)
+		sameSchema := len(parts) < 2 || strings.EqualFold(parts[len(parts)-2], schema)
+		if !sameTable || !sameSchema {
+			msg := fmt.Sprintf("predicate for table %s references another object (%s)", table, strings.TrimSpace(match[0]))
+			return apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+		}
+	}
+	return nil
+}
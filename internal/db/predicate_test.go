@@ -0,0 +1,52 @@
+package db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+func TestValidatePredicate(t *testing.T) {
+	orders := NewTableName("dbo", "Orders")
+	tests := []struct {
+		name      string
+		predicate string
+		wantErr   string // Part of the error message, or "" if the predicate is accepted.
+	}{
+		{"plain column", "CreatedAt >= '2024-01-01'", ""},
+		{"table qualifier", "Orders.Status = 1", ""},
+		{"schema and table qualifier", "[dbo].[Orders].Col IS NOT NULL", ""},
+		{"qualifier in another case", "DBO.orders.[Total] > 10.5", ""},
+		{"keyword in a string literal", "Note = 'SELECT * FROM Users; DROP TABLE Orders --'", ""},
+		{"escaped quote in a string literal", "Name = N'O''Brien; DELETE'", ""},
+		{"function call", "YEAR(CreatedAt) = 2024 AND Total BETWEEN 1 AND 2", ""},
+		{"other table", "Customers.Id = 7", "references another object (Customers.Id)"},
+		{"other schema", "[sales].[Orders].Id = 7", "references another object"},
+		{"three-part reference", "Shop.dbo.Customers.Id = 7", "references another object"},
+		{"unterminated quote", "Name = 'open", "unterminated string literal"},
+		{"quote after a literal", "Name = 'a' OR Note = 'b", "unterminated string literal"},
+		{"statement separator", "1 = 1; DROP TABLE Orders", `may not contain ";"`},
+		{"subquery", "CustomerId IN (SELECT Id FROM Customers)", `may not contain "SELECT"`},
+		{"exists subquery", "EXISTS (select 1 from Users)", `may not contain "select"`},
+		{"line comment", "1 = 1 -- AND Status = 2", `may not contain "--"`},
+		{"block comment", "1 = 1 /* hidden */", `may not contain "/*"`},
+		{"union", "1 = 0 UNION ALL SELECT 1", `may not contain "UNION"`},
+		{"exec", "1 = 1 EXEC('x')", `may not contain "EXEC"`},
+		{"empty", "  ", "empty predicate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePredicate(orders, tt.predicate)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidatePredicate(%q) = %v, want it accepted", tt.predicate, err)
+				}
+				return
+			}
+			if !apperrors.HasCode(err, apperrors.ErrInvalidInput) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidatePredicate(%q) = %v, want an ErrInvalidInput saying %q", tt.predicate, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package db
+
+// ProgressReporter receives the progress of the driver's long-running tasks, such as the rows
+// of a data dump, so callers decide how to show it. Tasks run one after the other, but the steps
+// of a task may be reported from several goroutines.
+type ProgressReporter interface {
+	// Start begins a task made of total steps.
+	Start(task string, total int)
+
+	// Increment reports that n more steps of the current task are done.
+	Increment(n int)
+
+	// Done ends the current task.
+	Done()
+}
+
+// noProgress is the ProgressReporter used when Config.Progress is nil.
+type noProgress struct{}
+
+func (noProgress) Start(string, int) {}
+func (noProgress) Increment(int)     {}
+func (noProgress) Done()             {}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"time"
+)
+
+// DefaultTransientErrors lists the SQL Server error numbers that are worth retrying.
+var DefaultTransientErrors = []int32{
+	-2,    // Client-side timeout.
+	1205,  // Transaction was deadlocked and chosen as the victim.
+	1222,  // Lock request time out period exceeded.
+	40197, // Service error processing the request (Azure).
+	40501, // Service is currently busy (Azure).
+	40613, // Database is not currently available (Azure).
+}
+
+// DefaultRetryPolicy is the retry policy of a dump or copy that sets none: three retries of the
+// DefaultTransientErrors, the first after half a second.
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 3, Backoff: 500 * time.Millisecond, ErrorNumbers: DefaultTransientErrors}
+
+// RetryPolicy describes how transient database errors are retried.
+// Metadata queries are retried on their own. A table's data is retried as a whole by dumps, so
+// a deadlock while its rows are read starts the table over; copies only retry the query starting
+// the read, since the rows already inserted into the target could not be taken back.
+type RetryPolicy struct {
+	MaxRetries   int           // Number of retries after the first attempt. Zero disables retrying.
+	Backoff      time.Duration // Delay before the first retry, doubled on every following one.
+	ErrorNumbers []int32       // SQL Server error numbers considered transient.
+}
+
+// sqlErrorNumber is implemented by the mssql driver errors.
+type sqlErrorNumber interface {
+	SQLErrorNumber() int32
+}
+
+// isTransient reports whether err is worth retrying under this policy.
+func (p RetryPolicy) isTransient(err error) bool {
+	var numbered sqlErrorNumber
+	if errors.As(err, &numbered) {
+		return slices.Contains(p.ErrorNumbers, numbered.SQLErrorNumber())
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout()
+	}
+	return false
+}
+
+// withRetry calls fn until it succeeds, fails with a non-transient error,
+// or the policy runs out of retries. The context aborts the wait between attempts.
+func withRetry(ctx context.Context, p RetryPolicy, fn func() error) error {
+	backoff := p.Backoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxRetries || !p.isTransient(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,207 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/algermosen/go-erdos/util"
+)
+
+// SchemaDiff lists the differences between the schemas of a source and a target database.
+type SchemaDiff struct {
+	SourceOnlyTables      []TableName `json:"source_only_tables"`
+	TargetOnlyTables      []TableName `json:"target_only_tables"`
+	Tables                []TableDiff `json:"tables"`
+	SourceOnlyConstraints []string    `json:"source_only_constraints,omitempty"`
+	TargetOnlyConstraints []string    `json:"target_only_constraints,omitempty"`
+
+	// The compared mappings, for turning the differences into statements.
+	source, target TableMapping
+}
+
+// TableDiff lists the column differences of a table present in both databases.
+type TableDiff struct {
+	Table             TableName      `json:"table"`
+	SourceOnlyColumns []string       `json:"source_only_columns"`
+	TargetOnlyColumns []string       `json:"target_only_columns"`
+	ChangedColumns    []ColumnChange `json:"changed_columns"`
+}
+
+// ColumnChange is a column whose type, collation, nullability or identity differs between the databases.
+type ColumnChange struct {
+	Column string `json:"column"`
+	Source string `json:"source"` // e.g. "nvarchar(50) NOT NULL"
+	Target string `json:"target"`
+}
+
+// IsEmpty reports whether both schemas are the same.
+func (d SchemaDiff) IsEmpty() bool {
+	return len(d.SourceOnlyTables) == 0 && len(d.TargetOnlyTables) == 0 && len(d.Tables) == 0 &&
+		len(d.SourceOnlyConstraints) == 0 && len(d.TargetOnlyConstraints) == 0
+}
+
+// diffTableMappings compares the tables and columns of two databases.
+func diffTableMappings(source, target TableMapping) SchemaDiff {
+	diff := SchemaDiff{
+		SourceOnlyTables: []TableName{},
+		TargetOnlyTables: []TableName{},
+		Tables:           []TableDiff{},
+		source:           source,
+		target:           target,
+	}
+	for _, table := range source.tables() {
+		targetColumns, exists := target[table]
+		if !exists {
+			diff.SourceOnlyTables = append(diff.SourceOnlyTables, table)
+			continue
+		}
+		if tableDiff := diffColumns(table, source[table], targetColumns); tableDiff != nil {
+			diff.Tables = append(diff.Tables, *tableDiff)
+		}
+	}
+	for _, table := range target.tables() {
+		if _, exists := source[table]; !exists {
+			diff.TargetOnlyTables = append(diff.TargetOnlyTables, table)
+		}
+	}
+	return diff
+}
+
+// diffColumns compares the columns of a table, matching them by name regardless of case.
+// It returns nil if they are the same.
+func diffColumns(table TableName, source, target []columnDef) *TableDiff {
+	diff := TableDiff{Table: table, SourceOnlyColumns: []string{}, TargetOnlyColumns: []string{}, ChangedColumns: []ColumnChange{}}
+	for _, col := range source {
+		other, found := findColumn(target, col.columnName)
+		switch {
+		case !found:
+			diff.SourceOnlyColumns = append(diff.SourceOnlyColumns, col.columnName)
+		case describeColumn(col) != describeColumn(other):
+			diff.ChangedColumns = append(diff.ChangedColumns, ColumnChange{
+				Column: col.columnName,
+				Source: describeColumn(col),
+				Target: describeColumn(other),
+			})
+		}
+	}
+	for _, col := range target {
+		if _, found := findColumn(source, col.columnName); !found {
+			diff.TargetOnlyColumns = append(diff.TargetOnlyColumns, col.columnName)
+		}
+	}
+	if len(diff.SourceOnlyColumns) == 0 && len(diff.TargetOnlyColumns) == 0 && len(diff.ChangedColumns) == 0 {
+		return nil
+	}
+	return &diff
+}
+
+// findColumn returns the column with the given name, regardless of case.
+func findColumn(columns []columnDef, name string) (columnDef, bool) {
+	i := slices.IndexFunc(columns, func(col columnDef) bool {
+		return strings.EqualFold(col.columnName, name)
+	})
+	if i < 0 {
+		return columnDef{}, false
+	}
+	return columns[i], true
+}
+
+// describeColumn returns the compared attributes of a column, e.g. "int NOT NULL IDENTITY".
+func describeColumn(cd columnDef) string {
+	colType := ColumnType{Name: cd.dataType, MaxLength: cd.maxLength, Precision: cd.precision, Scale: cd.scale}
+	desc := strings.ToLower(MSSQLTypeMapper{}.ColumnType(colType))
+	if cd.collation != "" {
+		desc += " COLLATE " + cd.collation
+	}
+	if cd.isNullable {
+		desc += " NULL"
+	} else {
+		desc += " NOT NULL"
+	}
+	if cd.isIdentity {
+		desc += " IDENTITY"
+	}
+	return desc
+}
+
+// diffConstraints compares constraints described as "table definition". Constraint names are left
+// out, since the server generates different ones for unnamed constraints in every database.
+func diffConstraints(source, target []string) (sourceOnly, targetOnly []string) {
+	for _, constraint := range source {
+		if !slices.Contains(target, constraint) {
+			sourceOnly = append(sourceOnly, constraint)
+		}
+	}
+	for _, constraint := range target {
+		if !slices.Contains(source, constraint) {
+			targetOnly = append(targetOnly, constraint)
+		}
+	}
+	return sourceOnly, targetOnly
+}
+
+// WriteText writes the differences as a human-readable report.
+func (d SchemaDiff) WriteText(w io.Writer) error {
+	var builder strings.Builder
+	if d.IsEmpty() {
+		builder.WriteString("No differences found.\n")
+	}
+	writeList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		builder.WriteString(title + ":\n")
+		for _, item := range items {
+			builder.WriteString(util.TabSpace + item + "\n")
+		}
+		builder.WriteString("\n")
+	}
+	tableNames := func(tables []TableName) []string {
+		names := make([]string, len(tables))
+		for i, table := range tables {
+			names[i] = table.String()
+		}
+		return names
+	}
+
+	writeList("Tables only in source", tableNames(d.SourceOnlyTables))
+	writeList("Tables only in target", tableNames(d.TargetOnlyTables))
+	for _, table := range d.Tables {
+		var lines []string
+		for _, col := range table.SourceOnlyColumns {
+			lines = append(lines, fmt.Sprintf("column %s only in source", FormatObjectName(col)))
+		}
+		for _, col := range table.TargetOnlyColumns {
+			lines = append(lines, fmt.Sprintf("column %s only in target", FormatObjectName(col)))
+		}
+		for _, change := range table.ChangedColumns {
+			lines = append(lines, fmt.Sprintf("column %s is %s in source, %s in target", FormatObjectName(change.Column), change.Source, change.Target))
+		}
+		writeList(table.Table.String(), lines)
+	}
+	writeList("Constraints only in source", d.SourceOnlyConstraints)
+	writeList("Constraints only in target", d.TargetOnlyConstraints)
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// WriteJSON writes the differences as a JSON object.
+func (d SchemaDiff) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(d)
+}
+
+// tables returns the tables of the mapping, sorted by name.
+func (tm TableMapping) tables() []TableName {
+	tables := make([]TableName, 0, len(tm))
+	for table := range tm {
+		tables = append(tables, table)
+	}
+	slices.Sort(tables)
+	return tables
+}
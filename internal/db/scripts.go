@@ -0,0 +1,34 @@
+package db
+
+import (
+	"embed"
+	"fmt"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+// scripts holds the SQL files of the scripts directory, compiled into the binary so queries
+// do not depend on the working directory.
+//
+//go:embed scripts/*.sql
+var scripts embed.FS
+
+// loadScript returns the content of the embedded SQL file with the given name.
+func loadScript(name string) (string, error) {
+	data, err := scripts.ReadFile("scripts/" + name)
+	if err != nil {
+		msg := fmt.Sprintf("SQL script %s is not embedded", name)
+		return "", apperrors.New(apperrors.ErrFileRead, msg, err)
+	}
+	return string(data), nil
+}
+
+// mustLoadScript is like loadScript but panics if the file is missing. It is meant for the
+// package-level queries, so a misspelled name fails on startup rather than on first use.
+func mustLoadScript(name string) string {
+	query, err := loadScript(name)
+	if err != nil {
+		panic(err)
+	}
+	return query
+}
@@ -0,0 +1,10 @@
+SELECT DISTINCT
+    fk.TABLE_SCHEMA AS ChildSchema,
+    fk.TABLE_NAME AS ChildTable,
+    pk.TABLE_SCHEMA AS ParentSchema, 
+    pk.TABLE_NAME AS ParentTable 
+FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc 
+FULL JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS fk ON rc.CONSTRAINT_NAME = fk.CONSTRAINT_NAME 
+FULL JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS pk ON rc.UNIQUE_CONSTRAINT_NAME = pk.CONSTRAINT_NAME
+WHERE pk.TABLE_NAME IS NOT NULL
+ORDER BY fk.TABLE_NAME ASC;
@@ -0,0 +1,11 @@
+SELECT 
+    s.name AS [schema],
+    t.name AS [table],
+    c.name AS [column],
+    CAST(ep.value AS nvarchar(max)) AS [description]
+FROM sys.extended_properties ep
+JOIN sys.tables t ON ep.major_id = t.object_id
+JOIN sys.schemas s ON t.schema_id = s.schema_id
+LEFT JOIN sys.columns c ON ep.major_id = c.object_id AND ep.minor_id = c.column_id
+WHERE ep.class = 1 AND ep.name = 'MS_Description'
+ORDER BY s.name, t.name, ep.minor_id;
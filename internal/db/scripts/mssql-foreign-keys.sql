@@ -0,0 +1,18 @@
+SELECT 
+    fk.TABLE_SCHEMA AS ChildSchema,
+    fk.TABLE_NAME AS ChildTable,
+    fk.CONSTRAINT_NAME AS ForeignKey,
+    pk.TABLE_SCHEMA AS ParentSchema, 
+    pk.TABLE_NAME AS ParentTable,
+    fkc.COLUMN_NAME AS ChildColumn,
+    pkc.COLUMN_NAME AS ParentColumn,
+    rc.UPDATE_RULE,
+    rc.DELETE_RULE,
+    fkc.ORDINAL_POSITION
+FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc
+JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS fk ON rc.CONSTRAINT_NAME = fk.CONSTRAINT_NAME
+JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS pk ON rc.UNIQUE_CONSTRAINT_NAME = pk.CONSTRAINT_NAME
+JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE fkc ON fk.CONSTRAINT_NAME = fkc.CONSTRAINT_NAME
+JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE pkc ON pk.CONSTRAINT_NAME = pkc.CONSTRAINT_NAME 
+    AND fkc.ORDINAL_POSITION = pkc.ORDINAL_POSITION
+ORDER BY fk.TABLE_SCHEMA, fk.TABLE_NAME, fk.CONSTRAINT_NAME, fkc.ORDINAL_POSITION;
@@ -0,0 +1,23 @@
+SELECT 
+    s.name AS [schema],
+    t.name AS [table],
+    i.name AS [index],
+    i.is_unique,
+    i.type_desc,
+    i.fill_factor,
+    i.ignore_dup_key,
+    i.filter_definition,
+    c.name AS [column],
+    ic.is_included_column,
+    ic.is_descending_key
+FROM sys.indexes AS i
+JOIN sys.tables AS t ON i.object_id = t.object_id
+JOIN sys.schemas AS s ON t.schema_id = s.schema_id
+JOIN sys.index_columns AS ic ON i.object_id = ic.object_id AND i.index_id = ic.index_id
+JOIN sys.columns AS c ON ic.object_id = c.object_id AND ic.column_id = c.column_id
+WHERE i.type IN (1, 2) -- Clustered and nonclustered rowstore indexes.
+    AND i.is_primary_key = 0
+    AND i.is_unique_constraint = 0 -- Keys are dumped as constraints.
+    AND i.is_hypothetical = 0
+    AND t.is_ms_shipped = 0
+ORDER BY s.name, t.name, i.name, ic.is_included_column, ic.key_ordinal, ic.index_column_id;
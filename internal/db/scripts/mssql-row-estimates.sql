@@ -0,0 +1,14 @@
+SELECT 
+    s.name AS [schema],
+    t.name AS [table],
+    SUM(p.rows) AS [rows]
+FROM 
+    sys.tables t
+JOIN 
+    sys.schemas s ON t.schema_id = s.schema_id
+JOIN 
+    sys.partitions p ON t.object_id = p.object_id
+WHERE 
+    p.index_id IN (0, 1) -- Heap or clustered index only, so rows are not counted once per index.
+GROUP BY 
+    s.name, t.name
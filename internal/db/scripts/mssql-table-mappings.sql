@@ -0,0 +1,27 @@
+SELECT 
+    s.name AS [schema],
+    t.name AS [table],
+    c.name AS [column],
+    c.column_id AS [column_position],
+    tp.name AS [data_type],
+    c.max_length AS [max_length],
+    c.precision,
+    c.scale,
+    c.is_nullable AS [is_nullable],
+    c.is_identity AS [is_identity],
+    c.is_computed AS [is_computed],
+    -- Only collations differing from the database default are kept, as those are the ones to preserve.
+    CASE WHEN c.collation_name <> CAST(DATABASEPROPERTYEX(DB_NAME(), 'Collation') AS sysname)
+        THEN c.collation_name END AS [collation]
+FROM 
+    sys.tables t
+JOIN 
+    sys.schemas s ON t.schema_id = s.schema_id
+JOIN 
+    sys.columns c ON t.object_id = c.object_id
+JOIN 
+    sys.types tp ON c.user_type_id = tp.user_type_id
+WHERE 
+    t.type = 'U'
+ORDER BY 
+    s.name, t.name, c.column_id
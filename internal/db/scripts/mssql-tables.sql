@@ -0,0 +1,8 @@
+SELECT 
+    TABLE_SCHEMA,
+    TABLE_NAME 
+FROM 
+    INFORMATION_SCHEMA.TABLES 
+WHERE 
+    TABLE_TYPE = 'BASE TABLE' 
+    AND TABLE_CATALOG = DB_NAME();
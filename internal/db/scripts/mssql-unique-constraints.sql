@@ -0,0 +1,18 @@
+SELECT 
+    s.name AS [schema],
+    t.name AS [table],
+    kc.name AS [constraint],
+    c.name AS [column],
+    ic.key_ordinal,
+    i.type_desc,
+    i.fill_factor,
+    ic.is_descending_key
+FROM sys.key_constraints AS kc
+JOIN sys.tables AS t ON kc.parent_object_id = t.object_id
+JOIN sys.schemas AS s ON t.schema_id = s.schema_id
+JOIN sys.indexes AS i ON kc.parent_object_id = i.object_id AND kc.unique_index_id = i.index_id -- The index backing the key.
+JOIN sys.index_columns AS ic ON i.object_id = ic.object_id AND i.index_id = ic.index_id
+JOIN sys.columns AS c ON ic.object_id = c.object_id AND ic.column_id = c.column_id
+WHERE kc.type = 'UQ'
+    AND ic.key_ordinal > 0
+ORDER BY s.name, t.name, kc.name, ic.key_ordinal;
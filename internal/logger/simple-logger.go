@@ -4,15 +4,21 @@ import (
 	"io"
 	"log"
 	"os"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
 )
 
+// SimpleLogger writes informational messages to stdout and errors to stderr,
+// and both to the log file if one is given.
 type SimpleLogger struct {
-	logger *log.Logger
-	file   *os.File
+	logger    *log.Logger
+	errLogger *log.Logger
+	file      *os.File
 }
 
 func NewSimpleLogger(logFile string) (*SimpleLogger, error) {
 	var output io.Writer = os.Stdout
+	var errOutput io.Writer = os.Stderr
 	var file *os.File
 
 	if logFile != "" {
@@ -22,24 +28,54 @@ func NewSimpleLogger(logFile string) (*SimpleLogger, error) {
 		}
 
 		output = io.MultiWriter(os.Stdout, f)
+		errOutput = io.MultiWriter(os.Stderr, f)
 		file = f
 	}
 
-	l := log.New(output, "", log.LstdFlags)
 	return &SimpleLogger{
-		logger: l,
-		file:   file,
+		logger:    log.New(output, "[INFO] ", log.LstdFlags),
+		errLogger: log.New(errOutput, "[ERR] ", log.LstdFlags),
+		file:      file,
 	}, nil
 }
 
 func (l *SimpleLogger) Info(v ...interface{}) {
-	l.logger.SetPrefix("[INFO] ")
 	l.logger.Println(v...)
 }
 
 func (l *SimpleLogger) Error(v ...interface{}) {
-	l.logger.SetPrefix("[ERR] ")
-	l.logger.Println(v...)
+	l.errLogger.Println(withErrorContext(v)...)
+}
+
+// withErrorContext returns v with the table and column every error is about written after it
+// as fields of their own, e.g. "table=[dbo].[Orders] column=Email", so the log can be searched
+// by table. An AppError then leaves them out of its own message.
+func withErrorContext(v []interface{}) []interface{} {
+	out := make([]interface{}, 0, len(v))
+	for _, arg := range v {
+		err, ok := arg.(error)
+		if !ok {
+			out = append(out, arg)
+			continue
+		}
+		table, column := apperrors.TableOf(err), apperrors.ColumnOf(err)
+		if table == "" && column == "" {
+			out = append(out, arg)
+			continue
+		}
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			out = append(out, appErr.Summary())
+		} else {
+			out = append(out, err.Error())
+		}
+		if table != "" {
+			out = append(out, "table="+table)
+		}
+		if column != "" {
+			out = append(out, "column="+column)
+		}
+	}
+	return out
 }
 
 func (l *SimpleLogger) Close() error {
@@ -7,10 +7,18 @@ import (
 	"strings"
 
 	"github.com/algermosen/go-erdos/cmd"
+	"github.com/algermosen/go-erdos/internal/logger"
 	_ "github.com/denisenkom/go-mssqldb"
 )
 
 func main() {
+	appLogger, err := logger.NewSimpleLogger("")
+	if err != nil {
+		log.Fatalf("Failed to create logger: %v", err)
+	}
+	defer appLogger.Close()
+	cmd.SetLogger(appLogger)
+
 	cmd.Execute()
 	// // Define command-line flags
 	// sourceDBConn := flag.String("source", "", "Source database connection string")
@@ -0,0 +1,159 @@
+package erdos
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// script returns the text of a query of the mssql driver, read from its scripts directory.
+func script(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "internal", "db", "scripts", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// noRows returns an empty result with the given number of columns.
+func noRows(columns int) *sqlmock.Rows {
+	names := make([]string, columns)
+	for i := range names {
+		names[i] = string(rune('a' + i))
+	}
+	return sqlmock.NewRows(names)
+}
+
+func TestDumpAll(t *testing.T) {
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	// The sections run one after the other, but the queries within a section may run concurrently.
+	mock.MatchExpectationsInOrder(false)
+
+	// Customers has a primary key and two rows; the rows of Notes are skipped.
+	tables := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"schema", "table"}).AddRow("dbo", "Customers").AddRow("dbo", "Notes")
+	}
+	mappings := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"schema", "table", "column", "column_position", "data_type", "max_length",
+			"precision", "scale", "is_nullable", "is_identity", "is_computed", "collation"}).
+			AddRow("dbo", "Customers", "Id", 1, "int", 4, 10, 0, false, true, false, nil).
+			AddRow("dbo", "Customers", "Name", 2, "nvarchar", 100, 0, 0, true, false, false, nil).
+			AddRow("dbo", "Notes", "Text", 1, "nvarchar", 200, 0, 0, true, false, false, nil)
+	}
+	primaryKeys := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"schema", "table", "constraint", "column", "ordinal", "type_desc", "fill_factor", "is_descending"}).
+			AddRow("dbo", "Customers", "PK_Customers", "Id", 1, "CLUSTERED", 0, false)
+	}
+	for section := 0; section < 2; section++ {
+		mock.ExpectQuery(script(t, "mssql-dependencies.sql")).WillReturnRows(noRows(4))
+		mock.ExpectQuery(script(t, "mssql-tables.sql")).WillReturnRows(tables())
+		mock.ExpectQuery(script(t, "mssql-table-mappings.sql")).WillReturnRows(mappings())
+	}
+	mock.ExpectQuery(script(t, "mssql-primary-keys.sql")).WillReturnRows(primaryKeys())
+	mock.ExpectQuery(script(t, "mssql-row-estimates.sql")).WillReturnRows(
+		sqlmock.NewRows([]string{"schema", "table", "rows"}).AddRow("dbo", "Customers", 2).AddRow("dbo", "Notes", 5))
+	mock.ExpectQuery("SELECT [Id], [Name] FROM [dbo].[Customers] ORDER BY [Id]").WillReturnRows(
+		sqlmock.NewRows([]string{"Id", "Name"}).AddRow(1, "Ada").AddRow(2, "Grace"))
+	mock.ExpectQuery(script(t, "mssql-primary-keys.sql")).WillReturnRows(primaryKeys())
+	mock.ExpectQuery(script(t, "mssql-unique-constraints.sql")).WillReturnRows(noRows(8))
+	mock.ExpectQuery(script(t, "mssql-indexes.sql")).WillReturnRows(noRows(11))
+	mock.ExpectQuery(script(t, "mssql-foreign-keys.sql")).WillReturnRows(noRows(10))
+
+	dumper, err := New("mssql", conn)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer dumper.Close()
+	var out bytes.Buffer
+	stats, err := dumper.DumpAll(context.Background(), &out, Options{
+		Schema:      true,
+		Data:        true,
+		Constraints: true,
+		SkipData:    []string{"Notes"},
+		Concurrency: 2,
+	})
+	if err != nil {
+		t.Fatalf("DumpAll: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// The schema, data and constraints are written in that order.
+	dump := out.String()
+	var positions []int
+	for _, statement := range []string{
+		"CREATE TABLE [dbo].[Customers]",
+		"CREATE TABLE [dbo].[Notes]",
+		"INSERT INTO [dbo].[Customers] ([Id], [Name]) VALUES \n(1, 'Ada'),\n(2, 'Grace');",
+		"ALTER TABLE [dbo].[Customers] ADD CONSTRAINT [PK_Customers] PRIMARY KEY CLUSTERED ([Id]);",
+	} {
+		i := strings.Index(dump, statement)
+		if i < 0 {
+			t.Fatalf("dump lacks %q:\n%s", statement, dump)
+		}
+		positions = append(positions, i)
+	}
+	if !slices.IsSorted(positions) {
+		t.Errorf("sections out of order %v:\n%s", positions, dump)
+	}
+	if strings.Contains(dump, "INSERT INTO [dbo].[Notes]") {
+		t.Errorf("dump holds the skipped rows of Notes:\n%s", dump)
+	}
+
+	if stats.Tables != 2 || stats.Rows != 2 {
+		t.Errorf("stats = %d tables, %d rows, want 2 tables, 2 rows", stats.Tables, stats.Rows)
+	}
+	if !slices.Equal(stats.SkippedTables, []string{"[dbo].[Notes]"}) {
+		t.Errorf("skipped tables = %v, want [[dbo].[Notes]]", stats.SkippedTables)
+	}
+	if len(stats.FailedTables) != 0 {
+		t.Errorf("failed tables = %v, want none", stats.FailedTables)
+	}
+	if stats.Bytes != int64(out.Len()) {
+		t.Errorf("stats.Bytes = %d, want the %d bytes written", stats.Bytes, out.Len())
+	}
+}
+
+func TestDumpAllInvalidOptions(t *testing.T) {
+	conn, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	dumper, err := New("mssql", conn)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer dumper.Close()
+
+	// Invalid options fail before any query, so the mock expects none.
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"invalid pattern", Options{Data: true, SkipTables: []string{"re:("}}, "invalid table regex"},
+		{"predicate on another table", Options{Data: true, Where: map[string]string{"Orders": "Customers.Id = 1"}}, "Customers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := dumper.DumpAll(context.Background(), &out, tt.opts); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one mentioning %q", err, tt.want)
+			}
+			if out.Len() != 0 {
+				t.Errorf("DumpAll wrote %q", out.String())
+			}
+		})
+	}
+}
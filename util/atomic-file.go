@@ -0,0 +1,89 @@
+package util
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+// AtomicFile is written under a temporary name in the directory of its final path, and only
+// renamed to that path by Commit. A crash or error mid-write therefore leaves the previous
+// file at the path untouched, and readers never see a half-written one.
+type AtomicFile struct {
+	*os.File
+	path string
+	done bool
+}
+
+// CreateAtomic starts writing the file at path. Either Commit or Abort must be called.
+func CreateAtomic(path string) (*AtomicFile, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, apperrors.New(apperrors.ErrFileWrite, "failed to create a temporary file", err)
+	}
+	// CreateTemp makes the file private; it gets the mode of a regular output file instead.
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, apperrors.New(apperrors.ErrFileWrite, "failed to create a temporary file", err)
+	}
+	return &AtomicFile{File: file, path: path}, nil
+}
+
+// Commit flushes the file to disk and moves it to its final path, replacing what was there.
+func (f *AtomicFile) Commit() error {
+	return f.CommitAs(f.path)
+}
+
+// CommitAs is like Commit but moves the file to another path of the same directory, leaving
+// the final path untouched.
+func (f *AtomicFile) CommitAs(path string) error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+	if err := f.Sync(); err != nil {
+		f.discard()
+		return apperrors.New(apperrors.ErrFileWrite, "failed to write "+path, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return apperrors.New(apperrors.ErrFileWrite, "failed to write "+path, err)
+	}
+	// A rename within a file system is atomic, so the path holds the old or the new file.
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return apperrors.New(apperrors.ErrFileWrite, "failed to move the file into place at "+path, err)
+	}
+	return nil
+}
+
+// Abort discards the file, leaving the final path untouched. It does nothing once the file is
+// committed, so it can be deferred right after CreateAtomic.
+func (f *AtomicFile) Abort() {
+	if !f.done {
+		f.done = true
+		f.discard()
+	}
+}
+
+func (f *AtomicFile) discard() {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// ToFile writes the file at path with write, through an AtomicFile: the file is only replaced
+// once write succeeds.
+func ToFile(path string, write func(w io.Writer) error) error {
+	file, err := CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+	if err := write(file); err != nil {
+		return err
+	}
+	return file.Commit()
+}
@@ -0,0 +1,117 @@
+package util
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// dirEntries returns the names of the files in dir.
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+// readFile returns the content of the file at path.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	return string(data)
+}
+
+func TestToFileFailedWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dump.sql")
+	if err := os.WriteFile(path, []byte("old dump\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	failure := errors.New("connection lost")
+	err := ToFile(path, func(w io.Writer) error {
+		if _, err := io.WriteString(w, "half of the new dump"); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("ToFile error = %v, want %v", err, failure)
+	}
+	if got := readFile(t, path); got != "old dump\n" {
+		t.Errorf("file = %q, want the old content", got)
+	}
+	if names := dirEntries(t, dir); len(names) != 1 {
+		t.Errorf("directory holds %q, want only dump.sql", names)
+	}
+}
+
+func TestAtomicFileCommit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dump.sql")
+	if err := os.WriteFile(path, []byte("old dump\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := CreateAtomic(path)
+	if err != nil {
+		t.Fatalf("CreateAtomic: %v", err)
+	}
+	if _, err := io.WriteString(file, "new dump\n"); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	// Until the commit, the path keeps the old file.
+	if got := readFile(t, path); got != "old dump\n" {
+		t.Errorf("file before Commit = %q, want the old content", got)
+	}
+	if err := file.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if got := readFile(t, path); got != "new dump\n" {
+		t.Errorf("file = %q, want the new content", got)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0644 {
+		t.Errorf("mode = %v, want 0644", mode)
+	}
+
+	// Abort after Commit is a no-op: the committed file stays.
+	file.Abort()
+	if got := readFile(t, path); got != "new dump\n" {
+		t.Errorf("file after Abort = %q, want the committed content", got)
+	}
+	if names := dirEntries(t, dir); len(names) != 1 {
+		t.Errorf("directory holds %q, want only dump.sql", names)
+	}
+}
+
+func TestAtomicFileAbort(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dump.sql")
+
+	file, err := CreateAtomic(path)
+	if err != nil {
+		t.Fatalf("CreateAtomic: %v", err)
+	}
+	if _, err := io.WriteString(file, "discarded\n"); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	file.Abort()
+	if names := dirEntries(t, dir); len(names) != 0 {
+		t.Errorf("directory holds %q, want nothing", names)
+	}
+}
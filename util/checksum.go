@@ -0,0 +1,81 @@
+package util
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+// ChecksumSuffix is appended to a file name to get the name of its checksum manifest.
+const ChecksumSuffix = ".sha256"
+
+// ChecksumWriter passes writes through to an underlying writer, hashing them with SHA-256
+// on the way, so a file's checksum is known once it is written without reading it back.
+type ChecksumWriter struct {
+	w    io.Writer
+	hash hash.Hash
+}
+
+// NewChecksumWriter returns a ChecksumWriter writing to w.
+func NewChecksumWriter(w io.Writer) *ChecksumWriter {
+	return &ChecksumWriter{w: w, hash: sha256.New()}
+}
+
+// Write writes p to the underlying writer and hashes the bytes that were written.
+func (c *ChecksumWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.hash.Write(p[:n])
+	return n, err
+}
+
+// Sum returns the hex-encoded SHA-256 of everything written so far.
+func (c *ChecksumWriter) Sum() string {
+	return hex.EncodeToString(c.hash.Sum(nil))
+}
+
+// WriteChecksumFile writes the manifest of the file at path, holding the given checksum.
+// The manifest uses the sha256sum format, so "sha256sum -c" can check it as well.
+func WriteChecksumFile(path, sum string) error {
+	manifest := fmt.Sprintf("%s  %s\n", sum, filepath.Base(path))
+	if err := os.WriteFile(path+ChecksumSuffix, []byte(manifest), 0644); err != nil {
+		return apperrors.New(apperrors.ErrFileWrite, "failed to write checksum manifest", err)
+	}
+	return nil
+}
+
+// VerifyChecksumFile recomputes the checksum of the file at path and compares it with its manifest.
+func VerifyChecksumFile(path string) error {
+	manifest, err := os.ReadFile(path + ChecksumSuffix)
+	if err != nil {
+		return apperrors.New(apperrors.ErrFileRead, "failed to read checksum manifest", err)
+	}
+	expected, _, _ := strings.Cut(strings.TrimSpace(string(manifest)), " ")
+	if len(expected) != sha256.Size*2 {
+		msg := fmt.Sprintf("invalid checksum manifest %s", path+ChecksumSuffix)
+		return apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return apperrors.New(apperrors.ErrFileRead, "failed to open file to verify", err)
+	}
+	defer file.Close()
+
+	checksum := NewChecksumWriter(io.Discard)
+	if _, err := io.Copy(checksum, bufio.NewReader(file)); err != nil {
+		return apperrors.New(apperrors.ErrFileRead, "failed to read file to verify", err)
+	}
+	if !strings.EqualFold(checksum.Sum(), expected) {
+		msg := fmt.Sprintf("checksum mismatch for %s: the file is truncated or was modified", path)
+		return apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+	}
+	return nil
+}
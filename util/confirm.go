@@ -0,0 +1,49 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+// Confirm prints question to w and reads the answer from r. Only "y" and "yes", in any case,
+// confirm; anything else, including an empty answer or the end of the input, declines.
+func Confirm(r io.Reader, w io.Writer, question string) (bool, error) {
+	fmt.Fprint(w, question)
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, apperrors.New(apperrors.ErrFileRead, "failed to read the answer", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// ConfirmModify asks on the terminal before an operation modifies target, unless assumeYes is
+// set. When standard input is not a terminal (scripts, CI), nobody can answer, so the
+// operation is refused and --yes is required instead.
+func ConfirmModify(target string, assumeYes bool) error {
+	if assumeYes {
+		return nil
+	}
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		msg := fmt.Sprintf("refusing to modify %s without confirmation: pass --yes when not running interactively", target)
+		return apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+	}
+	ok, err := Confirm(os.Stdin, os.Stderr, fmt.Sprintf("This will modify %s. Continue? [y/N] ", target))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperrors.New(apperrors.ErrInterrupted, "canceled, "+target+" was not modified", nil)
+	}
+	return nil
+}
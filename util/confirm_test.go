@@ -0,0 +1,76 @@
+package util
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{"y", "y\n", true},
+		{"yes in capitals", "YES\n", true},
+		{"yes with spaces and CRLF", "  yes \r\n", true},
+		{"yes without newline", "yes", true},
+		{"no", "n\n", false},
+		{"empty answer", "\n", false},
+		{"other answer", "sure\n", false},
+		{"only the first line counts", "no\nyes\n", false},
+		{"EOF", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			got, err := Confirm(strings.NewReader(tt.input), &out, "Continue? [y/N] ")
+			if err != nil {
+				t.Fatalf("Confirm: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Confirm() with input %q = %v, want %v", tt.input, got, tt.want)
+			}
+			if out.String() != "Continue? [y/N] " {
+				t.Errorf("question written = %q", out.String())
+			}
+		})
+	}
+}
+
+func TestConfirmReadError(t *testing.T) {
+	failure := errors.New("read failed")
+	ok, err := Confirm(iotest.ErrReader(failure), &strings.Builder{}, "Continue? ")
+	if ok || !apperrors.HasCode(err, apperrors.ErrFileRead) || !errors.Is(err, failure) {
+		t.Errorf("Confirm() = %v, %v, want false and an ErrFileRead", ok, err)
+	}
+}
+
+func TestConfirmModifyNotInteractive(t *testing.T) {
+	// A pipe is no terminal, as when the command runs from a script.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	defer r.Close()
+	defer w.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+
+	if _, err := w.WriteString("yes\n"); err != nil {
+		t.Fatal(err)
+	}
+	err = ConfirmModify("Shop on db01", false)
+	if !apperrors.HasCode(err, apperrors.ErrInvalidInput) || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("ConfirmModify() = %v, want an ErrInvalidInput asking for --yes", err)
+	}
+	if err := ConfirmModify("Shop on db01", true); err != nil {
+		t.Errorf("ConfirmModify() with --yes = %v, want nil", err)
+	}
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+// ConnEnvVar is the environment variable holding the connection string.
+const ConnEnvVar = "ERDOS_CONN"
+
+// ResolveConnString returns the connection string to use, keeping it off the command line if wanted.
+// The --conn flag wins over the ERDOS_CONN environment variable, which wins over the --conn-file file.
+func ResolveConnString(conn, connFile string) (string, error) {
+	if !IsEmpty(conn) {
+		return conn, nil
+	}
+	if env := os.Getenv(ConnEnvVar); !IsEmpty(env) {
+		return env, nil
+	}
+	if !IsEmpty(connFile) {
+		content, err := os.ReadFile(connFile)
+		if err != nil {
+			msg := fmt.Sprintf("failed to read connection string file %s", connFile)
+			return "", apperrors.New(apperrors.ErrInvalidInput, msg, err)
+		}
+		if conn := strings.TrimSpace(string(content)); conn != "" {
+			return conn, nil
+		}
+		msg := fmt.Sprintf("connection string file %s is empty", connFile)
+		return "", apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+	}
+	msg := fmt.Sprintf("a connection string is required (--conn, %s or --conn-file)", ConnEnvVar)
+	return "", apperrors.New(apperrors.ErrInvalidInput, msg, nil)
+}
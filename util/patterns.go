@@ -0,0 +1,74 @@
+package util
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+// RegexPrefix marks a table pattern as a regular expression instead of a glob.
+const RegexPrefix = "re:"
+
+// TablePatterns matches table names against a list of glob or regex patterns.
+//
+// Patterns always match the plain, unbracketed form of a name ("sales.audit_2024"),
+// never the bracketed FormatObjectName form ("[sales].[audit_2024]"), and ignore case.
+// A glob (see path.Match) containing a dot is matched against "schema.table", one without
+// a dot against the table name alone, so "audit_*" skips audit tables of every schema.
+// Brackets in a glob are a character class, as usual. A pattern starting with "re:" is a
+// regular expression matched against "schema.table"; anchor it to match the whole name.
+type TablePatterns []tablePattern
+
+type tablePattern struct {
+	glob  string
+	regex *regexp.Regexp
+}
+
+// ParseTablePatterns compiles the given patterns, failing on the first invalid one.
+func ParseTablePatterns(patterns []string) (TablePatterns, error) {
+	parsed := make(TablePatterns, 0, len(patterns))
+	for _, pattern := range patterns {
+		if expr, ok := strings.CutPrefix(pattern, RegexPrefix); ok {
+			regex, err := regexp.Compile("(?i)" + expr)
+			if err != nil {
+				msg := fmt.Sprintf("invalid table regex '%s'", expr)
+				return nil, apperrors.New(apperrors.ErrInvalidInput, msg, err)
+			}
+			parsed = append(parsed, tablePattern{regex: regex})
+			continue
+		}
+
+		glob := strings.ToLower(pattern)
+		if _, err := path.Match(glob, ""); err != nil {
+			msg := fmt.Sprintf("invalid table pattern '%s'", pattern)
+			return nil, apperrors.New(apperrors.ErrInvalidInput, msg, err)
+		}
+		parsed = append(parsed, tablePattern{glob: glob})
+	}
+	return parsed, nil
+}
+
+// Match reports whether any of the patterns matches the table.
+func (p TablePatterns) Match(schema, table string) bool {
+	qualified := schema + "." + table
+	for _, pattern := range p {
+		if pattern.regex != nil {
+			if pattern.regex.MatchString(qualified) {
+				return true
+			}
+			continue
+		}
+
+		name := table
+		if strings.Contains(pattern.glob, ".") {
+			name = qualified
+		}
+		if matched, _ := path.Match(pattern.glob, strings.ToLower(name)); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/algermosen/go-erdos/internal/apperrors"
+)
+
+func TestTablePatternsMatch(t *testing.T) {
+	tests := []struct {
+		patterns      []string
+		schema, table string
+		want          bool
+	}{
+		{[]string{"Orders"}, "dbo", "Orders", true},
+		{[]string{"orders"}, "sales", "ORDERS", true},
+		{[]string{"Orders"}, "dbo", "OrderLines", false},
+		{[]string{"audit_*"}, "dbo", "audit_2024", true},
+		{[]string{"audit_*"}, "staging", "Audit_Logins", true},
+		{[]string{"audit_*"}, "audit_x", "Orders", false},
+		{[]string{"*_archive"}, "dbo", "orders_archive", true},
+		{[]string{"sales.*_archive"}, "sales", "orders_archive", true},
+		{[]string{"sales.*_archive"}, "dbo", "orders_archive", false},
+		{[]string{"*.Orders"}, "eu", "Orders", true},
+		{[]string{"dbo.Orders"}, "dbo", "Orders", true},
+		// Brackets are a character class, not quoting.
+		{[]string{"[dbo].[Orders]"}, "dbo", "Orders", false},
+		{[]string{"log[0-9]"}, "dbo", "log7", true},
+		{[]string{"log?"}, "dbo", "logs", true},
+		{[]string{"re:^staging\\.tmp_\\d+$"}, "staging", "tmp_42", true},
+		{[]string{"re:^staging\\.tmp_\\d+$"}, "staging", "tmp_x", false},
+		{[]string{"re:^staging\\.tmp_\\d+$"}, "dbo", "tmp_42", false},
+		{[]string{"re:ARCHIVE"}, "dbo", "orders_archive", true},
+		{[]string{"Orders", "audit_*"}, "dbo", "audit_1", true},
+		{nil, "dbo", "Orders", false},
+	}
+	for _, tt := range tests {
+		patterns, err := ParseTablePatterns(tt.patterns)
+		if err != nil {
+			t.Fatalf("ParseTablePatterns(%q): %v", tt.patterns, err)
+		}
+		if got := patterns.Match(tt.schema, tt.table); got != tt.want {
+			t.Errorf("%q.Match(%q, %q) = %v, want %v", tt.patterns, tt.schema, tt.table, got, tt.want)
+		}
+	}
+}
+
+func TestParseTablePatternsInvalid(t *testing.T) {
+	for _, pattern := range []string{"audit_[", "re:(unclosed"} {
+		if _, err := ParseTablePatterns([]string{"Orders", pattern}); !apperrors.HasCode(err, apperrors.ErrInvalidInput) {
+			t.Errorf("ParseTablePatterns(%q) error = %v, want an ErrInvalidInput", pattern, err)
+		}
+	}
+}
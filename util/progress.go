@@ -0,0 +1,130 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// quiet silences progress output, see SetQuiet.
+var quiet atomic.Bool
+
+// SetQuiet turns progress output off (or back on). Errors and results are not affected.
+func SetQuiet(q bool) {
+	quiet.Store(q)
+}
+
+// IsQuiet reports whether progress output is turned off.
+func IsQuiet() bool {
+	return quiet.Load()
+}
+
+// progressOutput is where progress lines go, see SetProgressOutput.
+var progressOutput atomic.Pointer[os.File]
+
+// interactive reports whether the progress output is a terminal that understands cursor movement.
+var interactive atomic.Bool
+
+func init() {
+	SetProgressOutput(os.Stdout)
+}
+
+// SetProgressOutput sends progress output to f instead of stdout, e.g. to stderr when stdout
+// carries the result. Cursor movement is only used if f is a terminal; NO_COLOR
+// (https://no-color.org) also turns the escape sequences off.
+func SetProgressOutput(f *os.File) {
+	progressOutput.Store(f)
+	_, noColor := os.LookupEnv("NO_COLOR")
+	info, err := f.Stat()
+	interactive.Store(!noColor && err == nil && info.Mode()&os.ModeCharDevice != 0)
+}
+
+// ProgressOutput returns where progress output goes, for status messages printed with it.
+func ProgressOutput() io.Writer {
+	return progressOutput.Load()
+}
+
+// IsInteractive reports whether progress lines replace each other in place.
+func IsInteractive() bool {
+	return interactive.Load()
+}
+
+// Progress prints a status line. On a terminal it replaces the previous line;
+// otherwise (log files, CI, pipes) every update is printed as a plain line.
+// Nothing is printed in quiet mode.
+func Progress(format string, args ...any) {
+	if IsQuiet() {
+		return
+	}
+	out := ProgressOutput()
+	if IsInteractive() {
+		fmt.Fprint(out, "\033[1A\033[K") // moves up and then deletes the line
+	}
+	fmt.Fprintf(out, format+"\n", args...)
+}
+
+// ProgressDone ends a run of Progress lines, so the last one is not replaced by what follows.
+func ProgressDone() {
+	if !IsQuiet() && IsInteractive() {
+		fmt.Fprintln(ProgressOutput())
+	}
+}
+
+// progressInterval is the least time between two lines of a TerminalProgress, so a task of
+// many small steps, such as the rows of a data dump, does not flood a log with lines.
+const progressInterval = 100 * time.Millisecond
+
+// TerminalProgress reports the progress of a task as "[task (done/total)]" lines printed with
+// Progress, at most one every progressInterval. It is safe for concurrent use.
+type TerminalProgress struct {
+	mu          sync.Mutex
+	task        string
+	done, total int
+	printed     time.Time
+	pending     bool
+}
+
+// NewTerminalProgress returns a TerminalProgress with no task started.
+func NewTerminalProgress() *TerminalProgress {
+	return &TerminalProgress{}
+}
+
+// Start begins a task made of total steps and prints its first line.
+func (p *TerminalProgress) Start(task string, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.task, p.done, p.total = task, 0, total
+	p.print()
+}
+
+// Increment adds n done steps and prints the updated line, unless one was printed less than
+// progressInterval ago and the task is not complete yet.
+func (p *TerminalProgress) Increment(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += n
+	if p.done < p.total && time.Since(p.printed) < progressInterval {
+		p.pending = true
+		return
+	}
+	p.print()
+}
+
+// Done ends the task, keeping its last line.
+func (p *TerminalProgress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pending {
+		p.print()
+	}
+	ProgressDone()
+}
+
+// print prints the current line. It is called with mu held.
+func (p *TerminalProgress) print() {
+	Progress("[%s (%d/%d)]", p.task, p.done, p.total)
+	p.printed, p.pending = time.Now(), false
+}